@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/backfill"
+	"github.com/clambin/github-exporter/internal/stats"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var backfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Reconstruct the star and fork history of the configured repos",
+	Long: `Reconstruct the star and fork history of the configured repos from the stargazer and fork timestamps reported by
+the GitHub API. For each repo, backfill writes the daily number of stars and forks since the repo's first star or fork.
+
+Stars that were later removed are not reported by GitHub, so the history only reflects the repo's current stargazers.`,
+	Args: cobra.NoArgs,
+	Run:  Backfill,
+}
+
+func Backfill(cmd *cobra.Command, _ []string) {
+	if repos, _ := cmd.Flags().GetStringSlice("repo"); len(repos) > 0 {
+		viper.Set("repos.repo", repos)
+		viper.Set("repos.user", []string{})
+		viper.Set("repos.topics", []any{})
+		viper.Set("repos.teams", []any{})
+		viper.Set("repos.property_filters", []any{})
+		viper.Set("sources", []any{})
+	}
+	format, _ := cmd.Flags().GetString("output")
+	write, ok := backfill.Writers[format]
+	if !ok {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "invalid output format %q\n", format)
+		os.Exit(1)
+	}
+
+	logger := newLogger()
+	sources, err := newGitHubSources(prometheus.NewRegistry())
+	if err != nil {
+		logger.Error("failed to create github client", "err", err)
+		os.Exit(1)
+	}
+	propertyFilters, err := newPropertyFilters()
+	if err != nil {
+		logger.Error("invalid configuration", "err", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var series []backfill.Series
+	for _, source := range sources {
+		c := stats.Client{
+			GitHubClient:    source.Client,
+			Logger:          logger,
+			Topics:          topics(source.Repos.Topics),
+			TeamTracker:     newTeamTracker(source.Repos.Teams),
+			PropertyFilters: propertyFilters,
+		}
+		repos, err := c.RepoNames(ctx, source.Repos.User, source.Repos.Repo)
+		if err != nil {
+			logger.Error("failed to get repos", "source", source.ID(), "err", err)
+			os.Exit(1)
+		}
+
+		b := backfill.Backfiller{
+			Client:          source.Client,
+			Logger:          logger.With("component", "backfill", "source", source.ID()),
+			Host:            source.Host(),
+			IncludeArchived: viper.GetBool("repos.archived"),
+		}
+		sourceSeries, err := b.Backfill(ctx, repos, time.Now())
+		if err != nil {
+			logger.Error("failed to backfill some repos", "source", source.ID(), "err", err)
+		}
+		series = append(series, sourceSeries...)
+	}
+
+	var w io.Writer = cmd.OutOrStdout()
+	if filename, _ := cmd.Flags().GetString("file"); filename != "" {
+		f, err := os.Create(filename)
+		if err != nil {
+			logger.Error("failed to create output file", "err", err)
+			os.Exit(1)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	if err = write(w, series); err != nil {
+		logger.Error("failed to write history", "err", err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	backfillCmd.Flags().StringP("output", "o", "openmetrics", "Output format (openmetrics, json, csv)")
+	backfillCmd.Flags().StringP("file", "f", "", "Write the history to this file instead of stdout")
+	backfillCmd.Flags().StringSlice("repo", nil, "Repo to backfill (overrides the configuration file)")
+	cmd.AddCommand(backfillCmd)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var collectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Collect metrics and print them to stdout",
+	Args:  cobra.NoArgs,
+	Run:   Collect,
+}
+
+func Collect(cmd *cobra.Command, _ []string) {
+	if repos, _ := cmd.Flags().GetStringSlice("repo"); len(repos) > 0 {
+		viper.Set("repos.repo", repos)
+		viper.Set("repos.user", []string{})
+		viper.Set("repos.topics", []any{})
+		viper.Set("repos.teams", []any{})
+		viper.Set("repos.property_filters", []any{})
+		viper.Set("sources", []any{})
+	}
+	format, _ := cmd.Flags().GetString("output")
+	if _, ok := writers[format]; !ok {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "invalid output format %q\n", format)
+		os.Exit(1)
+	}
+
+	logger := newLogger()
+	c, err := newCollector(logger, prometheus.NewRegistry())
+	if err != nil {
+		logger.Error("failed to create collector", "err", err)
+		os.Exit(1)
+	}
+	r := prometheus.NewRegistry()
+	r.MustRegister(c)
+
+	if once, _ := cmd.Flags().GetBool("once"); once {
+		if err = collect(cmd.OutOrStdout(), r, format); err != nil {
+			logger.Error("failed to collect metrics", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	for {
+		if err = collect(cmd.OutOrStdout(), r, format); err != nil {
+			logger.Error("failed to collect metrics", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(viper.GetDuration("git.cache")):
+		}
+	}
+}
+
+// collect gathers all metrics from g and writes them to w in the requested format. If some metrics could not
+// be gathered, the remaining metrics are still written.
+func collect(w io.Writer, g prometheus.Gatherer, format string) error {
+	mfs, gatherErr := g.Gather()
+	if len(mfs) == 0 {
+		return gatherErr
+	}
+	return errors.Join(gatherErr, writers[format](w, mfs))
+}
+
+var writers = map[string]func(io.Writer, []*dto.MetricFamily) error{
+	"prometheus": writeText,
+	"json":       writeJSON,
+	"csv":        writeCSV,
+}
+
+func writeText(w io.Writer, mfs []*dto.MetricFamily) error {
+	enc := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sample struct {
+	Labels map[string]string `json:"labels"`
+	Name   string            `json:"name"`
+	Value  float64           `json:"value"`
+}
+
+func writeJSON(w io.Writer, mfs []*dto.MetricFamily) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(samples(mfs))
+}
+
+func writeCSV(w io.Writer, mfs []*dto.MetricFamily) error {
+	s := samples(mfs)
+	var labelNames []string
+	for _, entry := range s {
+		for name := range entry.Labels {
+			if !slices.Contains(labelNames, name) {
+				labelNames = append(labelNames, name)
+			}
+		}
+	}
+	slices.Sort(labelNames)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(append(append([]string{"metric"}, labelNames...), "value"))
+	for _, entry := range s {
+		record := make([]string, 0, len(labelNames)+2)
+		record = append(record, entry.Name)
+		for _, name := range labelNames {
+			record = append(record, entry.Labels[name])
+		}
+		record = append(record, strconv.FormatFloat(entry.Value, 'f', -1, 64))
+		_ = cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// samples flattens the metric families into individual samples. As in the Prometheus text format, histograms are
+// reported as their _bucket (with an le label), _sum and _count samples, and summaries as their quantiles (with a
+// quantile label), _sum and _count samples.
+func samples(mfs []*dto.MetricFamily) []sample {
+	var s []sample
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string, len(m.GetLabel()))
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				s = append(s, sample{Name: mf.GetName(), Labels: labels, Value: m.GetCounter().GetValue()})
+			case dto.MetricType_GAUGE:
+				s = append(s, sample{Name: mf.GetName(), Labels: labels, Value: m.GetGauge().GetValue()})
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					s = append(s, sample{Name: mf.GetName() + "_bucket", Labels: withLabel(labels, "le", formatFloat(b.GetUpperBound())), Value: float64(b.GetCumulativeCount())})
+				}
+				s = append(s,
+					sample{Name: mf.GetName() + "_bucket", Labels: withLabel(labels, "le", "+Inf"), Value: float64(h.GetSampleCount())},
+					sample{Name: mf.GetName() + "_sum", Labels: labels, Value: h.GetSampleSum()},
+					sample{Name: mf.GetName() + "_count", Labels: labels, Value: float64(h.GetSampleCount())},
+				)
+			case dto.MetricType_SUMMARY:
+				summary := m.GetSummary()
+				for _, q := range summary.GetQuantile() {
+					s = append(s, sample{Name: mf.GetName(), Labels: withLabel(labels, "quantile", formatFloat(q.GetQuantile())), Value: q.GetValue()})
+				}
+				s = append(s,
+					sample{Name: mf.GetName() + "_sum", Labels: labels, Value: summary.GetSampleSum()},
+					sample{Name: mf.GetName() + "_count", Labels: labels, Value: float64(summary.GetSampleCount())},
+				)
+			default:
+				s = append(s, sample{Name: mf.GetName(), Labels: labels, Value: m.GetUntyped().GetValue()})
+			}
+		}
+	}
+	return s
+}
+
+// withLabel returns a copy of the labels, with the label name set to value.
+func withLabel(labels map[string]string, name string, value string) map[string]string {
+	l := maps.Clone(labels)
+	l[name] = value
+	return l
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func init() {
+	collectCmd.Flags().Bool("once", false, "Collect metrics once and exit")
+	collectCmd.Flags().StringP("output", "o", "prometheus", "Output format (prometheus, json, csv)")
+	collectCmd.Flags().StringSlice("repo", nil, "Repo to collect (overrides the configuration file)")
+	cmd.AddCommand(collectCmd)
+}
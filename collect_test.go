@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/collector"
+	"github.com/clambin/github-exporter/internal/fakegithub"
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "prometheus",
+			want: `# HELP github_exporter_forks Total number of forks
+# TYPE github_exporter_forks gauge
+github_exporter_forks{archived="false",host="github.com",repo="foo/bar"} 1
+# HELP github_exporter_issues Total number of open issues
+# TYPE github_exporter_issues gauge
+github_exporter_issues{archived="false",host="github.com",repo="foo/bar"} 2
+# HELP github_exporter_pulls Total number of open pull requests
+# TYPE github_exporter_pulls gauge
+github_exporter_pulls{archived="false",host="github.com",repo="foo/bar"} 3
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="github.com",repo="foo/bar"} 4
+`,
+		},
+		{
+			format: "csv",
+			want: `metric,archived,host,repo,value
+github_exporter_forks,false,github.com,foo/bar,1
+github_exporter_issues,false,github.com,foo/bar,2
+github_exporter_pulls,false,github.com,foo/bar,3
+github_exporter_stars,false,github.com,foo/bar,4
+`,
+		},
+		{
+			format: "json",
+			want: `[
+  {
+    "labels": {
+      "archived": "false",
+      "host": "github.com",
+      "repo": "foo/bar"
+    },
+    "name": "github_exporter_forks",
+    "value": 1
+  },
+  {
+    "labels": {
+      "archived": "false",
+      "host": "github.com",
+      "repo": "foo/bar"
+    },
+    "name": "github_exporter_issues",
+    "value": 2
+  },
+  {
+    "labels": {
+      "archived": "false",
+      "host": "github.com",
+      "repo": "foo/bar"
+    },
+    "name": "github_exporter_pulls",
+    "value": 3
+  },
+  {
+    "labels": {
+      "archived": "false",
+      "host": "github.com",
+      "repo": "foo/bar"
+    },
+    "name": "github_exporter_stars",
+    "value": 4
+  }
+]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			r := prometheus.NewRegistry()
+			r.MustRegister(&collector.Collector{
+				Sources: []collector.Source{{
+					Host:   "github.com",
+					Client: fakeStatsClient{stats: []github.RepoStats{{Name: "foo/bar", Forks: 1, Issues: 2, PullRequests: 3, Stars: 4}}},
+					Repos:  []string{"foo/bar"},
+				}},
+				Lifetime: time.Hour,
+				Logger:   slog.Default(),
+			})
+			var out bytes.Buffer
+			require.NoError(t, collect(&out, repoMetrics(r), tt.format))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestSamples_Summary(t *testing.T) {
+	r := prometheus.NewRegistry()
+	s := prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       "github_exporter_pull_time_to_merge_seconds",
+		Help:       "time to merge",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01},
+	}, []string{"repo"})
+	s.WithLabelValues("foo/bar").Observe(10)
+	s.WithLabelValues("foo/bar").Observe(20)
+	r.MustRegister(s)
+	mfs, err := r.Gather()
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, writeCSV(&out, mfs))
+	assert.Equal(t, `metric,quantile,repo,value
+github_exporter_pull_time_to_merge_seconds,0.5,foo/bar,10
+github_exporter_pull_time_to_merge_seconds,0.9,foo/bar,20
+github_exporter_pull_time_to_merge_seconds_sum,,foo/bar,30
+github_exporter_pull_time_to_merge_seconds_count,,foo/bar,2
+`, out.String())
+}
+
+func TestCollect_Repo(t *testing.T) {
+	s := fakegithub.New(
+		fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10},
+		fakegithub.Repo{Owner: "foo", Name: "snafu", Stars: 5},
+	)
+	t.Cleanup(s.Close)
+	setupConfig(t, map[string]any{
+		"repos.user": []string{"foo"},
+		"git.url":    s.BaseURL(),
+	})
+	flags := collectCmd.Flags()
+	require.NoError(t, flags.Set("once", "true"))
+	require.NoError(t, flags.Set("repo", "foo/snafu"))
+	t.Cleanup(func() {
+		_ = flags.Set("once", "false")
+		_ = flags.Lookup("repo").Value.(pflag.SliceValue).Replace(nil)
+	})
+
+	var out bytes.Buffer
+	collectCmd.SetOut(&out)
+	t.Cleanup(func() { collectCmd.SetOut(nil) })
+	Collect(collectCmd, nil)
+
+	// --repo replaces the configured repos
+	assert.Contains(t, out.String(), `github_exporter_stars{archived="false",host="127.0.0.1",repo="snafu"} 5`)
+	assert.NotContains(t, out.String(), `repo="bar"`)
+	assert.Zero(t, s.Requests("/users/foo/repos"))
+	assert.Zero(t, s.Requests("/repos/foo/bar"))
+}
+
+// repoMetrics only returns the repo metrics gathered by g.
+func repoMetrics(g prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := g.Gather()
+		return slices.DeleteFunc(mfs, func(mf *dto.MetricFamily) bool {
+			return !slices.Contains([]string{"github_exporter_forks", "github_exporter_issues", "github_exporter_pulls", "github_exporter_stars"}, mf.GetName())
+		}), err
+	})
+}
+
+var _ collector.StatClient = fakeStatsClient{}
+
+type fakeStatsClient struct {
+	err   error
+	stats []github.RepoStats
+}
+
+func (f fakeStatsClient) GetRepoStats(_ context.Context, _ []string, _ []string) ([]github.RepoStats, error) {
+	return f.stats, f.err
+}
@@ -1,25 +1,44 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
 	"time"
 
 	"codeberg.org/clambin/go-common/httputils/metrics"
 	"github.com/clambin/github-exporter/internal/collector"
+	"github.com/clambin/github-exporter/internal/config"
+	"github.com/clambin/github-exporter/internal/otlp"
+	"github.com/clambin/github-exporter/internal/push"
 	"github.com/clambin/github-exporter/internal/stats"
 	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/clambin/github-exporter/internal/token"
+	"github.com/clambin/github-exporter/internal/tracing"
+	"github.com/clambin/github-exporter/internal/webhook"
 	"github.com/clambin/github-exporter/limiter"
+	"github.com/clambin/github-exporter/recorder"
+	"github.com/clambin/github-exporter/retry"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/oauth2"
 )
 
 var (
 	configFilename string
+	recordDir      string
+	replayDir      string
 	version        = "change-me"
 	cmd            = &cobra.Command{
 		Use:     "github-exporter",
@@ -37,61 +56,466 @@ func main() {
 }
 
 func Main(cmd *cobra.Command, _ []string) {
-	var opts slog.HandlerOptions
+	logger := newLogger()
+
+	logger.Info(cmd.Name()+" started", "version", cmd.Version, "cache", viper.GetDuration("git.cache"))
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if exporter := viper.GetString("tracing.exporter"); exporter != "" {
+		tp, err := tracing.NewTracerProvider(ctx, tracing.Config{
+			Exporter: exporter,
+			Protocol: viper.GetString("tracing.protocol"),
+			Endpoint: viper.GetString("tracing.endpoint"),
+			Insecure: viper.GetBool("tracing.insecure"),
+			Headers:  viper.GetStringMapString("tracing.headers"),
+		}, otlp.NewResource(viper.GetStringMapString("otlp.resource"), cmd.Version))
+		if err != nil {
+			logger.Error("failed to create tracer", "err", err)
+			os.Exit(1)
+		}
+		otel.SetTracerProvider(tp)
+		defer func() { _ = tp.Shutdown(context.Background()) }()
+	}
+
+	c, err := newCollector(logger, prometheus.DefaultRegisterer)
+	if err != nil {
+		logger.Error("failed to create collector", "err", err)
+		os.Exit(1)
+	}
+	prometheus.MustRegister(c)
+
+	if target := viper.GetString("push.target"); target != "" {
+		p, err := newPusher(target, c, logger.With("component", "push"))
+		if err != nil {
+			logger.Error("failed to create pusher", "err", err)
+			os.Exit(1)
+		}
+		prometheus.MustRegister(p)
+		go p.Run(ctx)
+	}
+
+	if protocol := viper.GetString("otlp.protocol"); protocol != "" {
+		r := prometheus.NewRegistry()
+		r.MustRegister(c)
+		mp, err := otlp.NewMeterProvider(ctx, otlp.Config{
+			Protocol: protocol,
+			Endpoint: viper.GetString("otlp.endpoint"),
+			Insecure: viper.GetBool("otlp.insecure"),
+			Interval: viper.GetDuration("otlp.interval"),
+			Headers:  viper.GetStringMapString("otlp.headers"),
+			Resource: viper.GetStringMapString("otlp.resource"),
+		}, cmd.Version, r, logger.With("component", "otlp"))
+		if err != nil {
+			logger.Error("failed to create otlp exporter", "err", err)
+			os.Exit(1)
+		}
+		defer func() { _ = mp.Shutdown(context.Background()) }()
+	}
 
+	addr := viper.GetString("addr")
+	if addr == "" {
+		<-ctx.Done()
+		return
+	}
+	mux := http.NewServeMux()
+	// continue on errors, so the remaining metrics (incl. the collector's health metrics) are still served
+	mux.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, promhttp.HandlerFor(
+		prometheus.DefaultGatherer,
+		promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError, ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError)},
+	)))
+	if secret := viper.GetString("webhook.secret"); secret != "" {
+		h := webhook.New(c, []byte(secret), logger.With("component", "webhook"))
+		prometheus.MustRegister(h)
+		mux.Handle("/webhook", h)
+	}
+	if err = serve(ctx, addr, mux); err != nil {
+		logger.Error("failed to start http server", "err", err)
+		os.Exit(1)
+	}
+}
+
+// serve serves h on addr until ctx is done. The server then shuts down gracefully, waiting for active requests to
+// complete.
+func serve(ctx context.Context, addr string, h http.Handler) error {
+	srv := http.Server{Addr: addr, Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newPusher creates a Pusher that pushes the metrics of the collector c to the configured target.
+func newPusher(target string, c prometheus.Collector, logger *slog.Logger) (*push.Pusher, error) {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	var t push.Target
+	switch target {
+	case "pushgateway":
+		t = push.Pushgateway{
+			URL:        viper.GetString("push.url"),
+			Job:        viper.GetString("push.job"),
+			Grouping:   viper.GetStringMapString("push.grouping"),
+			Username:   viper.GetString("push.username"),
+			Password:   viper.GetString("push.password"),
+			HTTPClient: httpClient,
+		}
+	case "remote_write":
+		// copy the grouping labels, so the job label isn't added to the map returned by viper
+		labels := make(map[string]string)
+		maps.Copy(labels, viper.GetStringMapString("push.grouping"))
+		labels["job"] = viper.GetString("push.job")
+		t = push.RemoteWrite{
+			URL:        viper.GetString("push.url"),
+			Labels:     labels,
+			Username:   viper.GetString("push.username"),
+			Password:   viper.GetString("push.password"),
+			HTTPClient: httpClient,
+		}
+	default:
+		return nil, fmt.Errorf("invalid push target: %q", target)
+	}
+
+	p := push.Pusher{
+		Target:   t,
+		Logger:   logger,
+		Interval: viper.GetDuration("push.interval"),
+		Backoff:  viper.GetDuration("push.backoff"),
+		Retries:  viper.GetInt("push.retries"),
+	}
+	r := prometheus.NewRegistry()
+	r.MustRegister(c, &p)
+	p.Gatherer = r
+	return &p, nil
+}
+
+func newLogger() *slog.Logger {
+	var opts slog.HandlerOptions
 	if viper.GetBool("debug") {
 		opts.Level = slog.LevelDebug
 	}
-	logger := slog.New(slog.NewJSONHandler(os.Stderr, &opts))
+	return slog.New(slog.NewJSONHandler(os.Stderr, &opts))
+}
 
-	logger.Info(cmd.Name()+" started", "version", cmd.Version, "cache", viper.GetDuration("git.cache"))
+// newCollector creates a Collector for the configured sources. Metrics for the GitHub API clients are registered with r.
+func newCollector(logger *slog.Logger, r prometheus.Registerer) (*collector.Collector, error) {
+	gitHubSources, err := newGitHubSources(r)
+	if err != nil {
+		return nil, err
+	}
+	propertyFilters, err := newPropertyFilters()
+	if err != nil {
+		return nil, err
+	}
+	families, err := newFamilies()
+	if err != nil {
+		return nil, err
+	}
+	// only retrieve the data of the reported families
+	reported := func(names ...string) bool { return slices.ContainsFunc(names, families.Reported) }
+	var properties []string
+	if reported("github_exporter_repo_properties") {
+		properties = viper.GetStringSlice("repos.properties")
+	}
+	adaptive := newAdaptive()
+	sources := make([]collector.Source, len(gitHubSources))
+	for i, source := range gitHubSources {
+		sources[i] = collector.Source{
+			Client: stats.Client{
+				GitHubClient:         source.Client,
+				Logger:               logger.With("component", "github", "source", source.ID()),
+				MaxConcurrentRepos:   viper.GetInt("git.max_concurrent_repos"),
+				SkipPullRequestCount: !reported("github_exporter_pulls", "github_exporter_issues"),
+				IncludeActivity: viper.GetBool("repos.activity") && reported(
+					"github_exporter_weekly_commits", "github_exporter_weekly_additions", "github_exporter_weekly_deletions",
+					"github_exporter_contributors",
+				),
+				IncludeCompliance: viper.GetBool("repos.compliance") && reported(
+					"github_exporter_branch_protection", "github_exporter_repo_setting",
+				),
+				PullRequestTracker: newPullRequestTracker(reported(
+					"github_exporter_closed_pulls", "github_exporter_pull_size_lines",
+					"github_exporter_pull_time_to_first_review_seconds", "github_exporter_pull_time_to_merge_seconds",
+				)),
+				Topics:          topics(source.Repos.Topics),
+				TeamTracker:     newTeamTracker(source.Repos.Teams),
+				Properties:      properties,
+				PropertyFilters: propertyFilters,
+			},
+			Name:  source.ID(),
+			Host:  source.Host(),
+			Users: source.Repos.User,
+			Repos: source.Repos.Repo,
+		}
+		// the adaptive refresh interval needs the quota, even if it isn't reported
+		if adaptive != nil || reported(
+			"github_exporter_rate_limit", "github_exporter_rate_limit_remaining", "github_exporter_rate_limit_used",
+			"github_exporter_rate_limit_reset_timestamp_seconds", "github_exporter_rate_limit_exhaustion_timestamp_seconds",
+		) {
+			sources[i].Quotas = source.quotaClient()
+		}
+	}
+	return &collector.Collector{
+		Sources:         sources,
+		Adaptive:        adaptive,
+		IncludeArchived: viper.GetBool("repos.archived"),
+		TeamTopicPrefix: viper.GetString("repos.team_topic_prefix"),
+		Properties:      properties,
+		Families:        families,
+		Lifetime:        viper.GetDuration("git.cache"),
+		Logger:          logger.With("component", "collector"),
+	}, nil
+}
 
-	ctx := cmd.Context()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: viper.GetString("git.token")},
-	)
-	tc := oauth2.NewClient(ctx, ts)
+// newFamilies returns the metric families selected in the metrics section.
+func newFamilies() (collector.Families, error) {
+	families := collector.Families{
+		Enabled:  viper.GetStringSlice("metrics.enabled"),
+		Disabled: viper.GetStringSlice("metrics.disabled"),
+	}
+	if err := viper.UnmarshalKey("metrics.max_series", &families.MaxSeries); err != nil {
+		return families, fmt.Errorf("metrics.max_series: %w", err)
+	}
+	return families, nil
+}
+
+// newPullRequestTracker returns the tracker for closed pull requests, or nil if these statistics are disabled or not
+// reported.
+func newPullRequestTracker(reported bool) *stats.PullRequestTracker {
+	if !viper.GetBool("repos.pull_requests.enabled") || !reported {
+		return nil
+	}
+	return &stats.PullRequestTracker{Window: viper.GetDuration("repos.pull_requests.window")}
+}
+
+func topics(cfg []config.Topic) []stats.Topic {
+	topics := make([]stats.Topic, len(cfg))
+	for i, topic := range cfg {
+		topics[i] = stats.Topic(topic)
+	}
+	return topics
+}
+
+// newTeamTracker returns the tracker for the repos of the teams, or nil if no teams are configured.
+func newTeamTracker(cfg []config.Team) *stats.TeamTracker {
+	if len(cfg) == 0 {
+		return nil
+	}
+	teams := make([]stats.Team, len(cfg))
+	for i, team := range cfg {
+		teams[i] = stats.Team(team)
+	}
+	return &stats.TeamTracker{Teams: teams, Interval: viper.GetDuration("repos.team_cache")}
+}
+
+func newPropertyFilters() ([]stats.PropertyFilter, error) {
+	var cfg []config.PropertyFilter
+	if err := viper.UnmarshalKey("repos.property_filters", &cfg); err != nil {
+		return nil, fmt.Errorf("repos.property_filters: %w", err)
+	}
+	filters := make([]stats.PropertyFilter, len(cfg))
+	for i, filter := range cfg {
+		filters[i] = stats.PropertyFilter(filter)
+	}
+	return filters, nil
+}
+
+func newAdaptive() *collector.Adaptive {
+	if !viper.GetBool("git.adaptive.enabled") {
+		return nil
+	}
+	return &collector.Adaptive{
+		Budget:      viper.GetFloat64("git.adaptive.budget"),
+		MinInterval: viper.GetDuration("git.adaptive.min_interval"),
+	}
+}
+
+// sourceConfigs returns the configured GitHub sources: see config.Configuration.AllSources.
+func sourceConfigs() ([]config.Source, error) {
+	cfg := config.Configuration{
+		Repos: config.Repos{User: viper.GetStringSlice("repos.user"), Repo: viper.GetStringSlice("repos.repo")},
+		Git: config.Git{
+			URL:                   viper.GetString("git.url"),
+			Token:                 viper.GetString("git.token"),
+			TokenFile:             viper.GetString("git.token_file"),
+			TokenEnv:              viper.GetString("git.token_env"),
+			TokenCommand:          viper.GetStringSlice("git.token_command"),
+			Tokens:                viper.GetStringSlice("git.tokens"),
+			TokenTTL:              viper.GetDuration("git.token_ttl"),
+			MaxConcurrentRequests: viper.GetInt("git.max_concurrent_requests"),
+		},
+	}
+	if err := viper.UnmarshalKey("repos.topics", &cfg.Repos.Topics); err != nil {
+		return nil, fmt.Errorf("repos.topics: %w", err)
+	}
+	if err := viper.UnmarshalKey("repos.teams", &cfg.Repos.Teams); err != nil {
+		return nil, fmt.Errorf("repos.teams: %w", err)
+	}
+	if err := viper.UnmarshalKey("sources", &cfg.Sources); err != nil {
+		return nil, fmt.Errorf("sources: %w", err)
+	}
+	return cfg.AllSources(), nil
+}
+
+// gitHubSource is a configured source, with its GitHub API client.
+type gitHubSource struct {
+	Client *github.Client
+	// Pool holds the tokens of the source, if it uses multiple tokens.
+	Pool *token.Pool
+	config.Source
+}
+
+// quotaClient returns the client that reports the API quota of the source. Requests are spread across the tokens of a
+// Pool, so its quota is the total quota of its tokens.
+func (s gitHubSource) quotaClient() collector.QuotaClient {
+	if s.Pool == nil {
+		return s.Client
+	}
+	return poolQuotas{client: s.Client, pool: s.Pool}
+}
+
+// poolQuotas reports the total API quota of the tokens in a Pool that haven't been rejected by GitHub.
+type poolQuotas struct {
+	client collector.QuotaClient
+	pool   *token.Pool
+}
+
+func (p poolQuotas) GetQuotas(ctx context.Context) ([]github.Quota, error) {
+	var quotas [][]github.Quota
+	for _, id := range p.pool.IDs() {
+		q, err := p.client.GetQuotas(token.WithID(ctx, id))
+		if err != nil {
+			return nil, fmt.Errorf("token %s: %w", id, err)
+		}
+		quotas = append(quotas, q)
+	}
+	return github.TotalQuotas(quotas...), nil
+}
+
+// newGitHubSources creates a GitHub API client for each configured source. The API metrics are shared by all clients
+// and registered with r. Metrics of a source's tokens are registered with a host and a source label.
+func newGitHubSources(r prometheus.Registerer) ([]gitHubSource, error) {
+	configs, err := sourceConfigs()
+	if err != nil {
+		return nil, err
+	}
 
 	rm := metrics.NewRequestMetrics(metrics.Options{Namespace: "github", Subsystem: "exporter"})
 	im1 := metrics.NewInflightMetrics("github", "exporter", map[string]string{"stage": "pre"})
 	im2 := metrics.NewInflightMetrics("github", "exporter", map[string]string{"stage": "post"})
-	prometheus.MustRegister(rm, im1, im2)
+	r.MustRegister(rm, im1, im2)
+
+	rt := retry.New(viper.GetInt("git.retry.attempts"), viper.GetDuration("git.retry.backoff"), viper.GetDuration("git.retry.max_backoff"))
+	rt.Timeout = viper.GetDuration("git.timeout")
+	r.MustRegister(rt)
+
+	sources := make([]gitHubSource, 0, len(configs))
+	for _, cfg := range configs {
+		// the metrics, cache and recordings of a source are identified by its ID
+		if slices.ContainsFunc(sources, func(source gitHubSource) bool { return source.ID() == cfg.ID() }) {
+			return nil, fmt.Errorf("duplicate source %q: set name to tell sources on the same host apart", cfg.ID())
+		}
+		labels := prometheus.Labels{"host": cfg.Host(), "source": cfg.ID()}
+		tc, pool, err := newBaseTransport(cfg, prometheus.WrapRegistererWith(labels, r))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.ID(), err)
+		}
 
-	tp := im1.RoundTripper(
-		limiter.NewLimiter(25).RoundTripper(
-			im2.RoundTripper(
-				rm.RoundTripper(tc.Transport),
+		// each source has its own limiter, so a slow host doesn't hold up the others
+		tp := im1.RoundTripper(
+			rt.RoundTripper(
+				limiter.NewLimiter(int64(cfg.MaxConcurrentRequests)).RoundTripper(
+					im2.RoundTripper(
+						rm.RoundTripper(tracing.RoundTripper(tc)),
+					),
+				),
 			),
-		),
-	)
+		)
+
+		ghc, err := github.New(tp, cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: github client: %w", cfg.ID(), err)
+		}
+		sources = append(sources, gitHubSource{Client: ghc, Pool: pool, Source: cfg})
+	}
+	return sources, nil
+}
 
-	ghc, err := github.New(tp)
+// newBaseTransport returns the transport at the bottom of the RoundTripper chain of a source, and the source's token Pool
+// if it uses multiple tokens. With --replay, requests are served from a recording and no token is needed. With --record,
+// the requests sent to GitHub are recorded. Each source is recorded in its own subdirectory.
+func newBaseTransport(cfg config.Source, r prometheus.Registerer) (http.RoundTripper, *token.Pool, error) {
+	if replayDir != "" {
+		return recorder.Replayer{Dir: filepath.Join(replayDir, cfg.ID())}, nil, nil
+	}
+	tc, pool, err := newTokenTransport(cfg, r)
 	if err != nil {
-		logger.Error("failed to create github client", "err", err)
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("token: %w", err)
 	}
-	c := collector.Collector{
-		Client: stats.Client{
-			GitHubClient: ghc,
-			Logger:       logger.With("component", "github"),
-		},
-		Users:           viper.GetStringSlice("repos.user"),
-		Repos:           viper.GetStringSlice("repos.repo"),
-		IncludeArchived: viper.GetBool("repos.archived"),
-		Lifetime:        viper.GetDuration("git.cache"),
-		Logger:          logger.With("component", "collector"),
+	if recordDir != "" {
+		dir := filepath.Join(recordDir, cfg.ID())
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			return nil, nil, fmt.Errorf("record: %w", err)
+		}
+		tc = recorder.Recorder{Dir: dir}.RoundTripper(tc)
 	}
-	prometheus.MustRegister(&c)
+	return tc, pool, nil
+}
 
-	http.Handle("/metrics", promhttp.Handler())
-	_ = http.ListenAndServe(viper.GetString("addr"), nil)
+func newTokenTransport(cfg config.Source, r prometheus.Registerer) (http.RoundTripper, *token.Pool, error) {
+	if len(cfg.Tokens) > 0 {
+		pool := token.NewPool(cfg.Tokens...)
+		r.MustRegister(pool)
+		return pool.RoundTripper(http.DefaultTransport), pool, nil
+	}
+	ts, err := newTokenSource(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	// oauth2.NewClient wraps ts in a ReuseTokenSource, which caches tokens without an expiry forever.
+	// Use the transport directly, so ts is called for each request and rotated tokens are picked up.
+	return &oauth2.Transport{Source: ts}, nil, nil
+}
+
+func newTokenSource(cfg config.Source) (oauth2.TokenSource, error) {
+	switch {
+	case cfg.TokenFile != "":
+		return &token.FileSource{Path: cfg.TokenFile}, nil
+	case cfg.TokenEnv != "":
+		return token.EnvSource(cfg.TokenEnv), nil
+	case len(cfg.TokenCommand) > 0:
+		return &token.CommandSource{
+			Command: cfg.TokenCommand,
+			TTL:     cfg.TokenTTL,
+		}, nil
+	case cfg.Token != "":
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: cfg.Token}), nil
+	default:
+		return nil, errors.New("no github token configured")
+	}
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
-	cmd.Flags().StringVar(&configFilename, "config", "", "Configuration file")
-	cmd.Flags().Bool("debug", false, "Log debug messages")
-	_ = viper.BindPFlag("debug", cmd.Flags().Lookup("debug"))
+	cmd.PersistentFlags().StringVar(&configFilename, "config", "", "Configuration file")
+	cmd.PersistentFlags().Bool("debug", false, "Log debug messages")
+	_ = viper.BindPFlag("debug", cmd.PersistentFlags().Lookup("debug"))
+	cmd.PersistentFlags().StringVar(&recordDir, "record", "", "Record the GitHub API traffic in this directory")
+	cmd.PersistentFlags().StringVar(&replayDir, "replay", "", "Serve the GitHub API traffic recorded in this directory, without accessing GitHub")
+	cmd.MarkFlagsMutuallyExclusive("record", "replay")
 }
 
 func initConfig() {
@@ -108,8 +532,58 @@ func initConfig() {
 	viper.SetDefault("addr", ":9090")
 	viper.SetDefault("repos.user", []string{})
 	viper.SetDefault("repos.repo", []string{})
+	viper.SetDefault("repos.topics", []any{})
+	viper.SetDefault("repos.team_topic_prefix", "")
+	viper.SetDefault("repos.teams", []any{})
+	viper.SetDefault("repos.team_cache", 6*time.Hour)
+	viper.SetDefault("repos.properties", []string{})
+	viper.SetDefault("repos.property_filters", []any{})
 	viper.SetDefault("repos.archived", false)
+	viper.SetDefault("repos.activity", false)
+	viper.SetDefault("repos.compliance", false)
+	viper.SetDefault("repos.pull_requests.enabled", false)
+	viper.SetDefault("repos.pull_requests.window", 30*24*time.Hour)
 	viper.SetDefault("git.token", "")
+	viper.SetDefault("git.token_file", "")
+	viper.SetDefault("git.token_env", "")
+	viper.SetDefault("git.token_command", []string{})
+	viper.SetDefault("git.token_ttl", 15*time.Minute)
+	viper.SetDefault("git.tokens", []string{})
+	viper.SetDefault("git.url", "")
+	viper.SetDefault("git.timeout", 10*time.Second)
+	viper.SetDefault("git.max_concurrent_requests", 25)
+	viper.SetDefault("git.max_concurrent_repos", 10)
+	viper.SetDefault("git.adaptive.enabled", false)
+	viper.SetDefault("git.adaptive.budget", 0.5)
+	viper.SetDefault("git.adaptive.min_interval", time.Minute)
+	viper.SetDefault("git.retry.attempts", 3)
+	viper.SetDefault("git.retry.backoff", time.Second)
+	viper.SetDefault("git.retry.max_backoff", 10*time.Second)
+	viper.SetDefault("push.target", "")
+	viper.SetDefault("push.url", "")
+	viper.SetDefault("push.interval", time.Minute)
+	viper.SetDefault("push.job", "github-exporter")
+	viper.SetDefault("push.grouping", map[string]string{})
+	viper.SetDefault("push.username", "")
+	viper.SetDefault("push.password", "")
+	viper.SetDefault("push.retries", 3)
+	viper.SetDefault("push.backoff", time.Second)
+	viper.SetDefault("otlp.protocol", "")
+	viper.SetDefault("otlp.endpoint", "")
+	viper.SetDefault("otlp.insecure", false)
+	viper.SetDefault("otlp.interval", time.Minute)
+	viper.SetDefault("otlp.headers", map[string]string{})
+	viper.SetDefault("otlp.resource", map[string]string{})
+	viper.SetDefault("sources", []any{})
+	viper.SetDefault("metrics.enabled", []string{})
+	viper.SetDefault("metrics.disabled", []string{})
+	viper.SetDefault("metrics.max_series", map[string]int{})
+	viper.SetDefault("webhook.secret", "")
+	viper.SetDefault("tracing.exporter", "")
+	viper.SetDefault("tracing.protocol", "grpc")
+	viper.SetDefault("tracing.endpoint", "")
+	viper.SetDefault("tracing.insecure", false)
+	viper.SetDefault("tracing.headers", map[string]string{})
 	viper.SetDefault("git.cache", time.Hour)
 
 	viper.SetEnvPrefix("GITHUB_EXPORTER")
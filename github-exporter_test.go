@@ -0,0 +1,433 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/collector"
+	"github.com/clambin/github-exporter/internal/fakegithub"
+	"github.com/clambin/github-exporter/internal/push"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCollector(t *testing.T) {
+	s := fakegithub.New(
+		fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10, Forks: 2, OpenIssues: 3, PullRequests: []fakegithub.PullRequest{
+			{Number: 1, Open: true},
+			{Number: 2},
+		}},
+		fakegithub.Repo{Owner: "foo", Name: "snafu", Stars: 5},
+	)
+	t.Cleanup(s.Close)
+	// the retry transport recovers from transient errors
+	s.InjectFault(fakegithub.Fault{Path: "/repos/foo/bar", StatusCode: http.StatusBadGateway, Count: 1})
+
+	setupConfig(t, map[string]any{
+		"repos.user":            []string{"foo"},
+		"git.url":               s.BaseURL(),
+		"git.retry.attempts":    3,
+		"git.retry.backoff":     time.Millisecond,
+		"git.retry.max_backoff": time.Millisecond,
+	})
+
+	_, r := newTestCollector(t)
+
+	// refresh the cache first, so the retry metrics are up to date
+	_, err := r.Gather()
+	require.NoError(t, err)
+	assert.NoError(t, testutil.GatherAndCompare(r, bytes.NewBufferString(`
+# HELP github_exporter_api_retries_total Total number of retried GitHub API requests
+# TYPE github_exporter_api_retries_total counter
+github_exporter_api_retries_total{reason="server_error"} 1
+# HELP github_exporter_issues Total number of open issues
+# TYPE github_exporter_issues gauge
+github_exporter_issues{archived="false",host="127.0.0.1",repo="bar"} 3
+github_exporter_issues{archived="false",host="127.0.0.1",repo="snafu"} 0
+# HELP github_exporter_pulls Total number of open pull requests
+# TYPE github_exporter_pulls gauge
+github_exporter_pulls{archived="false",host="127.0.0.1",repo="bar"} 1
+github_exporter_pulls{archived="false",host="127.0.0.1",repo="snafu"} 0
+# HELP github_exporter_rate_limit Maximum number of GitHub API requests in the current rate limit window
+# TYPE github_exporter_rate_limit gauge
+github_exporter_rate_limit{host="127.0.0.1",resource="core",source="127.0.0.1"} 5000
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="127.0.0.1",repo="bar"} 10
+github_exporter_stars{archived="false",host="127.0.0.1",repo="snafu"} 5
+`), "github_exporter_api_retries_total", "github_exporter_issues", "github_exporter_pulls", "github_exporter_rate_limit", "github_exporter_stars"))
+}
+
+// setupConfig sets the configuration for the duration of the test: a git section for a fake GitHub server, without
+// retries, with the overrides applied. The overrides must include the server's git.url.
+func setupConfig(t *testing.T, overrides map[string]any) {
+	t.Helper()
+	t.Cleanup(viper.Reset)
+	values := map[string]any{
+		"git.token":                   "token",
+		"git.timeout":                 time.Second,
+		"git.max_concurrent_requests": 5,
+		"git.max_concurrent_repos":    2,
+		"git.retry.attempts":          1,
+		"git.cache":                   time.Hour,
+	}
+	maps.Copy(values, overrides)
+	for key, value := range values {
+		viper.Set(key, value)
+	}
+}
+
+// newTestCollector creates the collector for the configuration and registers it, and the API client metrics, with a
+// pedantic registry.
+func newTestCollector(t *testing.T) (*collector.Collector, *prometheus.Registry) {
+	t.Helper()
+	r := prometheus.NewPedanticRegistry()
+	c, err := newCollector(slog.Default(), r)
+	require.NoError(t, err)
+	r.MustRegister(c)
+	return c, r
+}
+
+func TestNewCollector_Sources(t *testing.T) {
+	s1 := fakegithub.New(fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10})
+	t.Cleanup(s1.Close)
+	s2 := fakegithub.New(fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 5})
+	t.Cleanup(s2.Close)
+
+	setupConfig(t, map[string]any{
+		"repos.user": []string{"foo"},
+		"git.url":    s1.BaseURL(),
+		// use a different host name for the second server, so its repos get a different host label
+		"sources": []map[string]any{{
+			"url":   strings.Replace(s2.BaseURL(), "127.0.0.1", "localhost", 1),
+			"token": "other-token",
+			"repos": map[string]any{"repo": []string{"foo/bar"}},
+		}},
+	})
+
+	_, r := newTestCollector(t)
+
+	assert.NoError(t, testutil.GatherAndCompare(r, bytes.NewBufferString(`
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="127.0.0.1",repo="bar"} 10
+github_exporter_stars{archived="false",host="localhost",repo="bar"} 5
+`), "github_exporter_stars"))
+	assert.Equal(t, 1, s2.Requests("/repos/foo/bar"))
+}
+
+func TestNewCollector_Sources_SameHost(t *testing.T) {
+	s1 := fakegithub.New(fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10})
+	t.Cleanup(s1.Close)
+	s2 := fakegithub.New(fakegithub.Repo{Owner: "foo", Name: "snafu", Stars: 5})
+	t.Cleanup(s2.Close)
+
+	source := map[string]any{
+		"url":    s2.BaseURL(),
+		"tokens": []string{"other-token"},
+		"repos":  map[string]any{"repo": []string{"foo/snafu"}},
+	}
+	setupConfig(t, map[string]any{
+		"repos.user": []string{"foo"},
+		"git.url":    s1.BaseURL(),
+		"git.tokens": []string{"token"},
+		"sources":    []map[string]any{source},
+	})
+
+	// both sources are on host 127.0.0.1, so the second one needs a name
+	_, err := newCollector(slog.Default(), prometheus.NewPedanticRegistry())
+	assert.ErrorContains(t, err, `duplicate source "127.0.0.1"`)
+
+	source["name"] = "private"
+	viper.Set("sources", []map[string]any{source})
+	_, r := newTestCollector(t)
+
+	assert.NoError(t, testutil.GatherAndCompare(r, bytes.NewBufferString(`
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="127.0.0.1",repo="bar"} 10
+github_exporter_stars{archived="false",host="127.0.0.1",repo="snafu"} 5
+`), "github_exporter_stars"))
+	assert.Equal(t, 1, s2.Requests("/repos/foo/snafu"))
+}
+
+func TestNewCollector_Topics(t *testing.T) {
+	s := fakegithub.New(
+		fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10, Topics: []string{"team-payments"}},
+		fakegithub.Repo{Owner: "foo", Name: "snafu", Stars: 5, Topics: []string{"team-platform"}},
+	)
+	t.Cleanup(s.Close)
+
+	setupConfig(t, map[string]any{
+		"repos.topics":            []map[string]any{{"org": "foo", "topic": "team-payments"}},
+		"repos.team_topic_prefix": "team-",
+		"git.url":                 s.BaseURL(),
+	})
+
+	_, r := newTestCollector(t)
+
+	assert.NoError(t, testutil.GatherAndCompare(r, bytes.NewBufferString(`
+# HELP github_exporter_repo_team Team that has access to the repo. Teams derived from the repo's topics have no org and no permission
+# TYPE github_exporter_repo_team gauge
+github_exporter_repo_team{archived="false",host="127.0.0.1",org="",permission="",repo="bar",team="payments"} 1
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="127.0.0.1",repo="bar"} 10
+`), "github_exporter_repo_team", "github_exporter_stars"))
+}
+
+func TestNewCollector_Teams(t *testing.T) {
+	s := fakegithub.New(
+		fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10, Teams: map[string]string{"payments": "admin"}},
+		fakegithub.Repo{Owner: "foo", Name: "snafu", Stars: 5, Teams: map[string]string{"platform": "write"}},
+	)
+	t.Cleanup(s.Close)
+
+	setupConfig(t, map[string]any{
+		"repos.teams":      []map[string]any{{"org": "foo", "team": "payments"}},
+		"repos.team_cache": time.Hour,
+		"git.url":          s.BaseURL(),
+	})
+
+	_, r := newTestCollector(t)
+
+	assert.NoError(t, testutil.GatherAndCompare(r, bytes.NewBufferString(`
+# HELP github_exporter_repo_team Team that has access to the repo. Teams derived from the repo's topics have no org and no permission
+# TYPE github_exporter_repo_team gauge
+github_exporter_repo_team{archived="false",host="127.0.0.1",org="foo",permission="admin",repo="bar",team="payments"} 1
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="127.0.0.1",repo="bar"} 10
+`), "github_exporter_repo_team", "github_exporter_stars"))
+	assert.Equal(t, 1, s.Requests("/orgs/foo/teams/payments/repos"))
+}
+
+func TestNewCollector_Properties(t *testing.T) {
+	s := fakegithub.New(
+		fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10, Properties: map[string]string{"service-tier": "1", "cost-center": "payments"}},
+		fakegithub.Repo{Owner: "foo", Name: "snafu", Stars: 5, Properties: map[string]string{"service-tier": "3"}},
+	)
+	t.Cleanup(s.Close)
+
+	setupConfig(t, map[string]any{
+		"repos.user":             []string{"foo"},
+		"repos.properties":       []string{"service-tier", "cost-center"},
+		"repos.property_filters": []map[string]any{{"property": "service-tier", "values": []string{"1", "2"}}},
+		"git.url":                s.BaseURL(),
+	})
+
+	_, r := newTestCollector(t)
+
+	assert.NoError(t, testutil.GatherAndCompare(r, bytes.NewBufferString(`
+# HELP github_exporter_repo_properties Custom properties of the repo
+# TYPE github_exporter_repo_properties gauge
+github_exporter_repo_properties{archived="false",cost_center="payments",host="127.0.0.1",repo="bar",service_tier="1"} 1
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="127.0.0.1",repo="bar"} 10
+`), "github_exporter_repo_properties", "github_exporter_stars"))
+	assert.Equal(t, 1, s.Requests("/orgs/foo/properties/values"))
+}
+
+func TestNewCollector_Compliance(t *testing.T) {
+	s := fakegithub.New(
+		fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10},
+		fakegithub.Repo{Owner: "foo", Name: "snafu", Stars: 5},
+	)
+	t.Cleanup(s.Close)
+	// the token doesn't have admin access to foo/bar
+	s.InjectFault(fakegithub.Fault{Path: "/repos/foo/bar/branches/main/protection", StatusCode: http.StatusForbidden})
+
+	setupConfig(t, map[string]any{
+		"repos.user":       []string{"foo"},
+		"repos.compliance": true,
+		"git.url":          s.BaseURL(),
+	})
+
+	_, r := newTestCollector(t)
+
+	// foo/bar is still reported, without its compliance metrics
+	assert.NoError(t, testutil.GatherAndCompare(r, bytes.NewBufferString(`
+# HELP github_exporter_branch_protection Protection rule of the default branch is enabled (1) or not (0)
+# TYPE github_exporter_branch_protection gauge
+github_exporter_branch_protection{archived="false",branch="main",host="127.0.0.1",repo="snafu",rule="force_push_allowed"} 0
+github_exporter_branch_protection{archived="false",branch="main",host="127.0.0.1",repo="snafu",rule="protected"} 0
+github_exporter_branch_protection{archived="false",branch="main",host="127.0.0.1",repo="snafu",rule="required_reviews"} 0
+github_exporter_branch_protection{archived="false",branch="main",host="127.0.0.1",repo="snafu",rule="required_status_checks"} 0
+github_exporter_branch_protection{archived="false",branch="main",host="127.0.0.1",repo="snafu",rule="signed_commits"} 0
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="127.0.0.1",repo="bar"} 10
+github_exporter_stars{archived="false",host="127.0.0.1",repo="snafu"} 5
+`), "github_exporter_branch_protection", "github_exporter_stars"))
+}
+
+func TestNewCollector_Families(t *testing.T) {
+	s := fakegithub.New(
+		fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10, Properties: map[string]string{"service-tier": "1"}},
+		fakegithub.Repo{Owner: "foo", Name: "snafu", Stars: 5, Properties: map[string]string{"service-tier": "3"}},
+	)
+	t.Cleanup(s.Close)
+
+	setupConfig(t, map[string]any{
+		"repos.user":                  []string{"foo"},
+		"repos.properties":            []string{"service-tier"},
+		"repos.activity":              true,
+		"repos.pull_requests.enabled": true,
+		"metrics.enabled":             []string{"github_exporter_stars", "github_exporter_repo_properties", "github_exporter_series_dropped_total"},
+		"metrics.disabled":            []string{"github_exporter_repo_properties"},
+		"metrics.max_series":          map[string]any{"github_exporter_stars": 1},
+		"git.url":                     s.BaseURL(),
+	})
+
+	c, _ := newTestCollector(t)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "github_exporter_stars"))
+	assert.NoError(t, testutil.CollectAndCompare(c, bytes.NewBufferString(`
+# HELP github_exporter_series_dropped_total Total number of series dropped because their family exceeded its maximum number of series
+# TYPE github_exporter_series_dropped_total counter
+github_exporter_series_dropped_total{family="github_exporter_stars"} 2
+`), "github_exporter_series_dropped_total"))
+	assert.Zero(t, testutil.CollectAndCount(c, "github_exporter_repo_properties", "github_exporter_rate_limit", "github_exporter_pulls"))
+
+	// the data of the families that aren't reported is not retrieved
+	for _, path := range []string{
+		"/orgs/foo/properties/values",
+		"/rate_limit",
+		"/repos/foo/bar/pulls",
+		"/repos/foo/bar/stats/commit_activity",
+		"/repos/foo/bar/stats/contributors",
+	} {
+		assert.Zero(t, s.Requests(path), path)
+	}
+	assert.Equal(t, 1, s.Requests("/repos/foo/bar"))
+}
+
+func TestNewCollector_TokenQuotas(t *testing.T) {
+	s := fakegithub.New(
+		fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10},
+		fakegithub.Repo{Owner: "foo", Name: "snafu", Stars: 5},
+	)
+	t.Cleanup(s.Close)
+	s.SetTokenRateLimit("token-1", 5000)
+	s.SetTokenRateLimit("token-2", 1000)
+
+	setupConfig(t, map[string]any{
+		"repos.user":                []string{"foo"},
+		"git.url":                   s.BaseURL(),
+		"git.tokens":                []string{"token-1", "token-2"},
+		"git.adaptive.enabled":      true,
+		"git.adaptive.budget":       0.5,
+		"git.adaptive.min_interval": time.Second,
+	})
+
+	_, r := newTestCollector(t)
+	mfs, err := r.Gather()
+	require.NoError(t, err)
+	values := make(map[string]float64)
+	for _, mf := range mfs {
+		switch name := mf.GetName(); name {
+		case "github_exporter_rate_limit", "github_exporter_rate_limit_remaining", "github_exporter_rate_limit_used", "github_exporter_refresh_interval_seconds":
+			require.Len(t, mf.GetMetric(), 1, name)
+			values[name] = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+
+	// the quota is the total quota of both tokens
+	assert.Equal(t, 6000.0, values["github_exporter_rate_limit"])
+	used := values["github_exporter_rate_limit_used"]
+	require.Positive(t, used)
+	assert.Equal(t, 6000-used, values["github_exporter_rate_limit_remaining"])
+	// a refresh may use half of the total quota each hour
+	assert.InDelta(t, used/3000*time.Hour.Seconds(), values["github_exporter_refresh_interval_seconds"], 1)
+}
+
+func TestNewCollector_RecordReplay(t *testing.T) {
+	s := fakegithub.New(fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10, OpenIssues: 3})
+	config := map[string]any{
+		"repos.user": []string{"foo"},
+		"git.url":    s.BaseURL(),
+	}
+	const want = `
+# HELP github_exporter_issues Total number of open issues
+# TYPE github_exporter_issues gauge
+github_exporter_issues{archived="false",host="127.0.0.1",repo="bar"} 3
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="127.0.0.1",repo="bar"} 10
+`
+
+	// record the traffic with the fake GitHub server
+	dir := t.TempDir()
+	setFlag(t, &recordDir, dir)
+	setupConfig(t, config)
+	_, r := newTestCollector(t)
+	assert.NoError(t, testutil.GatherAndCompare(r, bytes.NewBufferString(want), "github_exporter_issues", "github_exporter_stars"))
+	s.Close()
+
+	// replay the traffic without a server or a token
+	setFlag(t, &recordDir, "")
+	setFlag(t, &replayDir, dir)
+	config["git.token"] = ""
+	setupConfig(t, config)
+	_, r = newTestCollector(t)
+	assert.NoError(t, testutil.GatherAndCompare(r, bytes.NewBufferString(want), "github_exporter_issues", "github_exporter_stars"))
+}
+
+// setFlag sets a flag variable for the duration of the test.
+func setFlag(t *testing.T, flag *string, value string) {
+	t.Helper()
+	old := *flag
+	t.Cleanup(func() { *flag = old })
+	*flag = value
+}
+
+func TestMain_Shutdown(t *testing.T) {
+	s := fakegithub.New(fakegithub.Repo{Owner: "foo", Name: "bar"})
+	t.Cleanup(s.Close)
+	setupConfig(t, map[string]any{
+		"repos.user": []string{"foo"},
+		"git.url":    s.BaseURL(),
+		"addr":       "127.0.0.1:0",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := cobra.Command{Use: "github-exporter"}
+	c.SetContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		Main(&c, nil)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Main did not return after the context was cancelled")
+	}
+}
+
+func TestNewPusher(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	grouping := map[string]string{"instance": "test"}
+	viper.SetDefault("push.grouping", grouping)
+	viper.Set("push.job", "github-exporter")
+
+	p, err := newPusher("remote_write", prometheus.NewRegistry(), slog.Default())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"instance": "test", "job": "github-exporter"}, p.Target.(push.RemoteWrite).Labels)
+	// the job label isn't added to the configured grouping labels
+	assert.Equal(t, map[string]string{"instance": "test"}, grouping)
+	assert.Equal(t, map[string]string{"instance": "test"}, viper.GetStringMapString("push.grouping"))
+}
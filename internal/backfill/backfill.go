@@ -0,0 +1,117 @@
+// Package backfill reconstructs the history of a repo's stars and forks from the timestamps reported by the GitHub API.
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+)
+
+// Point is the value of a series at a given time.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a daily cumulative time series of a repo.
+type Series struct {
+	Labels map[string]string
+	Name   string
+	Help   string
+	Points []Point
+}
+
+// Client retrieves the history of a repo.
+type Client interface {
+	GetRepoStats(context.Context, string, string) (github.RepoStats, error)
+	GetStargazerTimes(context.Context, string, string) ([]time.Time, error)
+	GetForkTimes(context.Context, string, string) ([]time.Time, error)
+}
+
+// Backfiller creates the star and fork history of a set of repos. The series use the same names and labels as the
+// steady-state gauges reported by the collector, so they can be imported as the history of those gauges.
+type Backfiller struct {
+	Client Client
+	Logger *slog.Logger
+	// Host is the name of the GitHub host of the repos, reported in the host label.
+	Host            string
+	IncludeArchived bool
+}
+
+// Backfill returns the daily star and fork series for each repo, up to now. If some repos could not be retrieved,
+// the series of the remaining repos are returned, along with an error for each failed repo.
+func (b Backfiller) Backfill(ctx context.Context, repos []string, now time.Time) ([]Series, error) {
+	var stars, forks []Series
+	var errs []error
+	for _, repo := range repos {
+		repoStars, repoForks, err := b.backfill(ctx, repo, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", repo, err))
+			continue
+		}
+		stars = append(stars, repoStars...)
+		forks = append(forks, repoForks...)
+	}
+	return append(stars, forks...), errors.Join(errs...)
+}
+
+func (b Backfiller) backfill(ctx context.Context, repo string, now time.Time) ([]Series, []Series, error) {
+	user, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid repo name: %s", repo)
+	}
+	repoStats, err := b.Client.GetRepoStats(ctx, user, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !b.IncludeArchived && repoStats.Archived {
+		b.Logger.Debug("skipping archived repo", "repo", repo)
+		return nil, nil, nil
+	}
+	starredAt, err := b.Client.GetStargazerTimes(ctx, user, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stargazers: %w", err)
+	}
+	createdAt, err := b.Client.GetForkTimes(ctx, user, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("forks: %w", err)
+	}
+	b.Logger.Debug("repo history retrieved", "repo", repo, "stars", len(starredAt), "forks", len(createdAt))
+
+	labels := map[string]string{"host": b.Host, "repo": repoStats.Name, "archived": strconv.FormatBool(repoStats.Archived)}
+	return []Series{{Name: "github_exporter_stars", Help: "Total number of stars", Labels: labels, Points: Daily(starredAt, now)}},
+		[]Series{{Name: "github_exporter_forks", Help: "Total number of forks", Labels: labels, Points: Daily(createdAt, now)}},
+		nil
+}
+
+// Daily returns the cumulative number of events at midnight (UTC) of each day, from the day after the first event
+// up to now.
+func Daily(events []time.Time, now time.Time) []Point {
+	if len(events) == 0 {
+		return nil
+	}
+	events = slices.Clone(events)
+	slices.SortFunc(events, time.Time.Compare)
+
+	var points []Point
+	var count int
+	for day := midnight(events[0]).AddDate(0, 0, 1); !day.After(now); day = day.AddDate(0, 0, 1) {
+		for count < len(events) && events[count].Before(day) {
+			count++
+		}
+		points = append(points, Point{Time: day, Value: float64(count)})
+	}
+	return points
+}
+
+func midnight(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
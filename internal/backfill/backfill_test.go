@@ -0,0 +1,101 @@
+package backfill
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaily(t *testing.T) {
+	now := time.Date(2024, time.January, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		events []time.Time
+		want   []Point
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "daily",
+			events: []time.Time{
+				time.Date(2024, time.January, 3, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, time.January, 1, 23, 0, 0, 0, time.UTC),
+				time.Date(2024, time.January, 4, 10, 0, 0, 0, time.UTC),
+			},
+			want: []Point{
+				{Time: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC), Value: 2},
+				{Time: time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC), Value: 2},
+				{Time: time.Date(2024, time.January, 4, 0, 0, 0, 0, time.UTC), Value: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Daily(tt.events, now))
+		})
+	}
+}
+
+func TestBackfiller_Backfill(t *testing.T) {
+	now := time.Date(2024, time.January, 3, 12, 0, 0, 0, time.UTC)
+	b := Backfiller{
+		Client: fakeClient{
+			repos: map[string]github.RepoStats{
+				"foo/bar":      {Name: "bar"},
+				"foo/archived": {Name: "archived", Archived: true},
+			},
+			starredAt: []time.Time{time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)},
+			createdAt: []time.Time{time.Date(2024, time.January, 2, 10, 0, 0, 0, time.UTC)},
+		},
+		Logger: slog.Default(),
+		Host:   "github.com",
+	}
+
+	series, err := b.Backfill(context.Background(), []string{"foo/bar", "foo/archived", "foo/missing"}, now)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "foo/missing")
+
+	labels := map[string]string{"host": "github.com", "repo": "bar", "archived": "false"}
+	want := []Series{
+		{Name: "github_exporter_stars", Help: "Total number of stars", Labels: labels, Points: []Point{
+			{Time: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC), Value: 1},
+			{Time: time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC), Value: 1},
+		}},
+		{Name: "github_exporter_forks", Help: "Total number of forks", Labels: labels, Points: []Point{
+			{Time: time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC), Value: 1},
+		}},
+	}
+	assert.Equal(t, want, series)
+}
+
+var _ Client = fakeClient{}
+
+type fakeClient struct {
+	repos     map[string]github.RepoStats
+	starredAt []time.Time
+	createdAt []time.Time
+}
+
+func (f fakeClient) GetRepoStats(_ context.Context, user string, repo string) (github.RepoStats, error) {
+	repoStats, ok := f.repos[user+"/"+repo]
+	if !ok {
+		return github.RepoStats{}, assert.AnError
+	}
+	return repoStats, nil
+}
+
+func (f fakeClient) GetStargazerTimes(_ context.Context, _ string, _ string) ([]time.Time, error) {
+	return f.starredAt, nil
+}
+
+func (f fakeClient) GetForkTimes(_ context.Context, _ string, _ string) ([]time.Time, error) {
+	return f.createdAt, nil
+}
@@ -0,0 +1,113 @@
+package backfill
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Writers lists the supported output formats.
+var Writers = map[string]func(io.Writer, []Series) error{
+	"openmetrics": WriteOpenMetrics,
+	"json":        WriteJSON,
+	"csv":         WriteCSV,
+}
+
+// WriteOpenMetrics writes the series in OpenMetrics format, with a timestamp for each sample. The output can be
+// imported into Prometheus with `promtool tsdb create-blocks-from openmetrics`.
+func WriteOpenMetrics(w io.Writer, series []Series) error {
+	enc := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeOpenMetrics))
+	for _, mf := range metricFamilies(series) {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+	if closer, ok := enc.(expfmt.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// metricFamilies groups the series by name. Series with the same name must be written as one metric family.
+func metricFamilies(series []Series) []*dto.MetricFamily {
+	var mfs []*dto.MetricFamily
+	families := make(map[string]*dto.MetricFamily)
+	for _, s := range series {
+		mf, ok := families[s.Name]
+		if !ok {
+			mf = &dto.MetricFamily{Name: new(s.Name), Help: new(s.Help), Type: dto.MetricType_GAUGE.Enum()}
+			families[s.Name] = mf
+			mfs = append(mfs, mf)
+		}
+		var labels []*dto.LabelPair
+		for _, name := range slices.Sorted(maps.Keys(s.Labels)) {
+			labels = append(labels, &dto.LabelPair{Name: new(name), Value: new(s.Labels[name])})
+		}
+		for _, p := range s.Points {
+			mf.Metric = append(mf.Metric, &dto.Metric{
+				Label:       labels,
+				Gauge:       &dto.Gauge{Value: new(p.Value)},
+				TimestampMs: new(p.Time.UnixMilli()),
+			})
+		}
+	}
+	return mfs
+}
+
+type sample struct {
+	Labels    map[string]string `json:"labels"`
+	Timestamp time.Time         `json:"timestamp"`
+	Name      string            `json:"name"`
+	Value     float64           `json:"value"`
+}
+
+// WriteJSON writes the series as a list of samples.
+func WriteJSON(w io.Writer, series []Series) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(samples(series))
+}
+
+// WriteCSV writes the series as a CSV file, with one line per sample.
+func WriteCSV(w io.Writer, series []Series) error {
+	var labelNames []string
+	for _, s := range series {
+		for name := range s.Labels {
+			if !slices.Contains(labelNames, name) {
+				labelNames = append(labelNames, name)
+			}
+		}
+	}
+	slices.Sort(labelNames)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(append(append([]string{"metric"}, labelNames...), "timestamp", "value"))
+	for _, entry := range samples(series) {
+		record := make([]string, 0, len(labelNames)+3)
+		record = append(record, entry.Name)
+		for _, name := range labelNames {
+			record = append(record, entry.Labels[name])
+		}
+		record = append(record, entry.Timestamp.Format(time.RFC3339), strconv.FormatFloat(entry.Value, 'f', -1, 64))
+		_ = cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func samples(series []Series) []sample {
+	s := make([]sample, 0, len(series))
+	for _, entry := range series {
+		for _, p := range entry.Points {
+			s = append(s, sample{Name: entry.Name, Labels: entry.Labels, Timestamp: p.Time, Value: p.Value})
+		}
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+package backfill
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriters(t *testing.T) {
+	labels := map[string]string{"repo": "bar", "archived": "false"}
+	series := []Series{
+		{Name: "github_exporter_stars", Help: "Total number of stars", Labels: labels, Points: []Point{
+			{Time: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC), Value: 1},
+			{Time: time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC), Value: 2},
+		}},
+		{Name: "github_exporter_stars", Help: "Total number of stars", Labels: map[string]string{"repo": "snafu", "archived": "false"}, Points: []Point{
+			{Time: time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC), Value: 5},
+		}},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "openmetrics",
+			want: `# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",repo="bar"} 1.0 1.7041536e+09
+github_exporter_stars{archived="false",repo="bar"} 2.0 1.70424e+09
+github_exporter_stars{archived="false",repo="snafu"} 5.0 1.70424e+09
+# EOF
+`,
+		},
+		{
+			format: "csv",
+			want: `metric,archived,repo,timestamp,value
+github_exporter_stars,false,bar,2024-01-02T00:00:00Z,1
+github_exporter_stars,false,bar,2024-01-03T00:00:00Z,2
+github_exporter_stars,false,snafu,2024-01-03T00:00:00Z,5
+`,
+		},
+		{
+			format: "json",
+			want: `[
+  {
+    "labels": {
+      "archived": "false",
+      "repo": "bar"
+    },
+    "timestamp": "2024-01-02T00:00:00Z",
+    "name": "github_exporter_stars",
+    "value": 1
+  },
+  {
+    "labels": {
+      "archived": "false",
+      "repo": "bar"
+    },
+    "timestamp": "2024-01-03T00:00:00Z",
+    "name": "github_exporter_stars",
+    "value": 2
+  },
+  {
+    "labels": {
+      "archived": "false",
+      "repo": "snafu"
+    },
+    "timestamp": "2024-01-03T00:00:00Z",
+    "name": "github_exporter_stars",
+    "value": 5
+  }
+]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, Writers[tt.format](&out, series))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+)
+
+// Adaptive chooses the refresh interval from the number of requests a refresh costs and the remaining API quota.
+type Adaptive struct {
+	// Budget is the fraction of the core API quota that refreshes may use, e.g. 0.5.
+	Budget float64
+	// MinInterval is the shortest refresh interval. Collector.Lifetime is the longest refresh interval.
+	MinInterval time.Duration
+}
+
+// quotaWindow is the length of GitHub's core rate limit window.
+const quotaWindow = time.Hour
+
+// interval returns the shortest refresh interval that keeps refreshes within the budget, both of the hourly quota and of
+// the quota remaining until the window resets. before and after hold the quota reported before and after a refresh.
+// interval returns false if the cost of the refresh can't be determined, e.g. because the quota was reset during the
+// refresh.
+func (a Adaptive) interval(before []github.Quota, after []github.Quota, maxInterval time.Duration, now time.Time) (time.Duration, bool) {
+	b, ok1 := coreQuota(before)
+	q, ok2 := coreQuota(after)
+	if !ok1 || !ok2 || !b.Reset.Equal(q.Reset) || q.Used < b.Used || q.Limit <= 0 {
+		return 0, false
+	}
+	cost := float64(q.Used - b.Used)
+
+	interval := time.Duration(cost / (a.Budget * float64(q.Limit)) * float64(quotaWindow))
+	if untilReset := q.Reset.Sub(now); untilReset > 0 {
+		if q.Remaining <= 0 {
+			interval = maxInterval
+		} else {
+			interval = max(interval, time.Duration(cost/(a.Budget*float64(q.Remaining))*float64(untilReset)))
+		}
+	}
+	return min(max(interval, a.MinInterval), maxInterval), true
+}
+
+func coreQuota(quotas []github.Quota) (github.Quota, bool) {
+	for _, quota := range quotas {
+		if quota.Resource == "core" {
+			return quota, true
+		}
+	}
+	return github.Quota{}, false
+}
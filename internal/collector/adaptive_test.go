@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptive_interval(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(30 * time.Minute)
+	a := Adaptive{Budget: 0.5, MinInterval: time.Minute}
+
+	tests := []struct {
+		name   string
+		before []github.Quota
+		after  []github.Quota
+		want   time.Duration
+		wantOK bool
+	}{
+		{
+			// 250 requests per refresh, 2500 per hour in the budget: 10 refreshes per hour
+			name:   "hourly budget",
+			before: []github.Quota{{Resource: "core", Limit: 5000, Remaining: 5000, Reset: reset}},
+			after:  []github.Quota{{Resource: "core", Limit: 5000, Remaining: 4750, Used: 250, Reset: reset}},
+			want:   6 * time.Minute,
+			wantOK: true,
+		},
+		{
+			// 250 requests per refresh, 500 remaining in the budget for the next 30 minutes: 2 refreshes
+			name:   "remaining budget",
+			before: []github.Quota{{Resource: "core", Limit: 5000, Remaining: 1250, Used: 3750, Reset: reset}},
+			after:  []github.Quota{{Resource: "core", Limit: 5000, Remaining: 1000, Used: 4000, Reset: reset}},
+			want:   15 * time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "cheap refresh",
+			before: []github.Quota{{Resource: "core", Limit: 5000, Remaining: 5000, Reset: reset}},
+			after:  []github.Quota{{Resource: "core", Limit: 5000, Remaining: 4999, Used: 1, Reset: reset}},
+			want:   time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "expensive refresh",
+			before: []github.Quota{{Resource: "core", Limit: 5000, Remaining: 5000, Reset: reset}},
+			after:  []github.Quota{{Resource: "core", Limit: 5000, Remaining: 1000, Used: 4000, Reset: reset}},
+			want:   time.Hour,
+			wantOK: true,
+		},
+		{
+			name:   "quota exhausted",
+			before: []github.Quota{{Resource: "core", Limit: 5000, Remaining: 10, Used: 4990, Reset: reset}},
+			after:  []github.Quota{{Resource: "core", Limit: 5000, Used: 5000, Reset: reset}},
+			want:   time.Hour,
+			wantOK: true,
+		},
+		{
+			name:   "quota reset during refresh",
+			before: []github.Quota{{Resource: "core", Limit: 5000, Remaining: 10, Used: 4990, Reset: now}},
+			after:  []github.Quota{{Resource: "core", Limit: 5000, Remaining: 4750, Used: 250, Reset: reset}},
+		},
+		{
+			name:  "no quota",
+			after: []github.Quota{{Resource: "core", Limit: 5000, Remaining: 4750, Used: 250, Reset: reset}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval, ok := a.interval(tt.before, tt.after, time.Hour, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, interval)
+		})
+	}
+}
@@ -1,27 +1,76 @@
 package collector
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/clambin/github-exporter/internal/label"
 	"github.com/clambin/github-exporter/internal/stats/github"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ prometheus.Collector = &Collector{}
 
+var tracer = otel.Tracer("github.com/clambin/github-exporter/internal/collector")
+
 type Collector struct {
-	lastUpdate      time.Time
-	Client          StatClient
-	Logger          *slog.Logger
-	Users           []string
-	Repos           []string
-	cache           []github.RepoStats
-	Lifetime        time.Duration
+	lastUpdate time.Time
+	Logger     *slog.Logger
+	Sources    []Source
+	cache      map[string][]github.RepoStats
+	quotas     map[string]quotas
+	dropped    map[string]int
+	health     health
+	Lifetime   time.Duration
+	interval   time.Duration
+	// lock guards the cache and the refresh results. refreshLock serializes the refreshes.
 	lock            sync.RWMutex
+	refreshLock     sync.Mutex
+	droppedLock     sync.Mutex
+	properties      metric
+	propertiesOnce  sync.Once
 	IncludeArchived bool
+	// TeamTopicPrefix reports each repo topic that starts with the prefix as a team of the repo, e.g. with prefix "team-",
+	// topic team-payments is reported as team payments. If empty, no teams are reported.
+	TeamTopicPrefix string
+	// Properties lists the custom properties reported as labels of the repo_properties metric. See label.Property.
+	Properties []string
+	// Families selects the reported metric families and limits their number of series.
+	Families Families
+	// Adaptive chooses the refresh interval from the API quota. If nil, the cache is refreshed every Lifetime.
+	// Adaptive requires the sources' Quotas.
+	Adaptive *Adaptive
+}
+
+// Source is a GitHub host, with the users and repos to collect. The repo metrics of a source have a host label set to Host.
+type Source struct {
+	Client StatClient
+	// Quotas reports the GitHub API quota after each refresh. If the source spreads its requests across multiple tokens,
+	// Quotas must report their total quota. If nil, no quota metrics are reported.
+	Quotas QuotaClient
+	// Name identifies the source, e.g. in the source label of the quota metrics. Sources on the same Host must have
+	// different names. If empty, the source is identified by its Host.
+	Name  string
+	Host  string
+	Users []string
+	Repos []string
+}
+
+// id returns the name that identifies the source.
+func (s Source) id() string {
+	return cmp.Or(s.Name, s.Host)
 }
 
 type StatClient interface {
@@ -30,53 +79,344 @@ type StatClient interface {
 
 func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
 	for _, metric := range metrics {
-		ch <- metric
+		if c.Families.Reported(metric.name) {
+			ch <- metric.desc
+		}
+	}
+	if properties := c.propertiesMetric(); len(c.Properties) > 0 && c.Families.Reported(properties.name) {
+		ch <- properties.desc
 	}
 }
 
+// propertiesMetric returns the repo_properties metric, which has a label for each of the Properties.
+func (c *Collector) propertiesMetric() metric {
+	c.propertiesOnce.Do(func() {
+		labels := []string{"host", "repo", "archived"}
+		for _, property := range c.Properties {
+			labels = append(labels, label.Property(property))
+		}
+		c.properties = newMetric("repo_properties", "Custom properties of the repo", labels)
+	})
+	return c.properties
+}
+
 func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	collected := make(chan prometheus.Metric)
+	go func() {
+		defer close(collected)
+		c.collect(collected)
+	}()
+	names := maps.Clone(familyNames)
+	names[c.propertiesMetric().desc] = c.propertiesMetric().name
+	dropped := make(map[string]int)
+	c.Families.limit(ch, collected, names, dropped)
+	c.collectDropped(ch, dropped)
+}
+
+// collectDropped adds the series dropped by a collection to the total and reports the total of each limited family.
+func (c *Collector) collectDropped(ch chan<- prometheus.Metric, dropped map[string]int) {
+	c.droppedLock.Lock()
+	defer c.droppedLock.Unlock()
+	if c.dropped == nil {
+		c.dropped = make(map[string]int)
+	}
+	for family, count := range dropped {
+		c.dropped[family] += count
+	}
+	if !c.Families.Reported(seriesDroppedFamily) {
+		return
+	}
+	for family := range c.Families.MaxSeries {
+		ch <- prometheus.MustNewConstMetric(metrics["series_dropped"].desc, prometheus.CounterValue, float64(c.dropped[family]), family)
+	}
+}
+
+func (c *Collector) collect(ch chan<- prometheus.Metric) {
 	start := time.Now()
 	defer func() { c.Logger.Debug("collected", "duration", time.Since(start)) }()
 
-	repoStats, err := c.getStats()
+	ctx, span := tracer.Start(context.Background(), "collect")
+	defer span.End()
+
+	cache, err := c.getStats(ctx)
+	c.collectHealth(ch)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to collect github statistics")
 		c.Logger.Error("failed to collect github statistics", "err", err)
 		ch <- prometheus.NewInvalidMetric(prometheus.NewDesc("github_exporter_error", "Error getting github statistics", nil, nil), err)
 		return
 	}
 
+	for _, source := range c.Sources {
+		c.collectRepos(ch, source.Host, cache[source.id()])
+	}
+}
+
+func (c *Collector) collectRepos(ch chan<- prometheus.Metric, host string, repoStats []github.RepoStats) {
 	for _, repoStat := range repoStats {
-		c.Logger.Debug("repo found", "repo", repoStat.Name)
+		c.Logger.Debug("repo found", "host", host, "repo", repoStat.Name)
 
 		if !c.IncludeArchived && repoStat.Archived {
 			continue
 		}
 
 		archived := bool2string(repoStat.Archived)
-		ch <- prometheus.MustNewConstMetric(metrics["stars"], prometheus.GaugeValue, float64(repoStat.Stars), repoStat.Name, archived)
-		ch <- prometheus.MustNewConstMetric(metrics["forks"], prometheus.GaugeValue, float64(repoStat.Forks), repoStat.Name, archived)
-		ch <- prometheus.MustNewConstMetric(metrics["issues"], prometheus.GaugeValue, float64(repoStat.Issues), repoStat.Name, archived)
-		ch <- prometheus.MustNewConstMetric(metrics["pulls"], prometheus.GaugeValue, float64(repoStat.PullRequests), repoStat.Name, archived)
+		ch <- prometheus.MustNewConstMetric(metrics["stars"].desc, prometheus.GaugeValue, float64(repoStat.Stars), host, repoStat.Name, archived)
+		ch <- prometheus.MustNewConstMetric(metrics["forks"].desc, prometheus.GaugeValue, float64(repoStat.Forks), host, repoStat.Name, archived)
+		ch <- prometheus.MustNewConstMetric(metrics["issues"].desc, prometheus.GaugeValue, float64(repoStat.Issues), host, repoStat.Name, archived)
+		ch <- prometheus.MustNewConstMetric(metrics["pulls"].desc, prometheus.GaugeValue, float64(repoStat.PullRequests), host, repoStat.Name, archived)
+		collectInfo(ch, host, repoStat, archived)
+		c.collectTeams(ch, host, repoStat, archived)
+		c.collectProperties(ch, host, repoStat, archived)
+
+		if protection := repoStat.BranchProtection; protection != nil {
+			collectCompliance(ch, host, repoStat, *protection, archived)
+		}
+
+		if pullRequestStats := repoStat.PullRequestStats; pullRequestStats != nil {
+			collectPullRequests(ch, host, repoStat, *pullRequestStats, archived)
+		}
+
+		if activity := repoStat.Activity; activity != nil {
+			ch <- prometheus.MustNewConstMetric(metrics["weekly_commits"].desc, prometheus.GaugeValue, float64(activity.Commits), host, repoStat.Name, archived)
+			ch <- prometheus.MustNewConstMetric(metrics["weekly_additions"].desc, prometheus.GaugeValue, float64(activity.Additions), host, repoStat.Name, archived)
+			ch <- prometheus.MustNewConstMetric(metrics["weekly_deletions"].desc, prometheus.GaugeValue, float64(activity.Deletions), host, repoStat.Name, archived)
+			ch <- prometheus.MustNewConstMetric(metrics["contributors"].desc, prometheus.GaugeValue, float64(activity.Contributors4w), host, repoStat.Name, archived, "4w")
+			ch <- prometheus.MustNewConstMetric(metrics["contributors"].desc, prometheus.GaugeValue, float64(activity.Contributors52w), host, repoStat.Name, archived, "52w")
+		}
 	}
 }
 
-func (c *Collector) getStats() ([]github.RepoStats, error) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+func collectInfo(ch chan<- prometheus.Metric, host string, repoStat github.RepoStats, archived string) {
+	topics := slices.Sorted(slices.Values(repoStat.Topics))
+	ch <- prometheus.MustNewConstMetric(metrics["repo_info"].desc, prometheus.GaugeValue, 1, host, repoStat.Name, archived,
+		repoStat.Language, repoStat.DefaultBranch, repoStat.Visibility, bool2string(repoStat.Fork), bool2string(repoStat.Template),
+		repoStat.License, strings.Join(topics, ","),
+	)
+	ch <- prometheus.MustNewConstMetric(metrics["size"].desc, prometheus.GaugeValue, float64(repoStat.Size), host, repoStat.Name, archived)
+	ch <- prometheus.MustNewConstMetric(metrics["watchers"].desc, prometheus.GaugeValue, float64(repoStat.Watchers), host, repoStat.Name, archived)
+	timestamps := map[string]time.Time{
+		"created": repoStat.CreatedAt,
+		"pushed":  repoStat.PushedAt,
+		"updated": repoStat.UpdatedAt,
+	}
+	for name, timestamp := range timestamps {
+		if !timestamp.IsZero() {
+			ch <- prometheus.MustNewConstMetric(metrics[name].desc, prometheus.GaugeValue, float64(timestamp.Unix()), host, repoStat.Name, archived)
+		}
+	}
+}
 
-	if time.Since(c.lastUpdate) < c.Lifetime {
-		return c.cache, nil
+func (c *Collector) collectTeams(ch chan<- prometheus.Metric, host string, repoStat github.RepoStats, archived string) {
+	for _, access := range repoStat.Teams {
+		ch <- prometheus.MustNewConstMetric(metrics["repo_team"].desc, prometheus.GaugeValue, 1, host, repoStat.Name, archived, access.Org, access.Team, access.Permission)
 	}
+	if c.TeamTopicPrefix == "" {
+		return
+	}
+	for _, topic := range repoStat.Topics {
+		if team, ok := strings.CutPrefix(topic, c.TeamTopicPrefix); ok && team != "" {
+			ch <- prometheus.MustNewConstMetric(metrics["repo_team"].desc, prometheus.GaugeValue, 1, host, repoStat.Name, archived, "", team, "")
+		}
+	}
+}
+
+func (c *Collector) collectProperties(ch chan<- prometheus.Metric, host string, repoStat github.RepoStats, archived string) {
+	if len(c.Properties) == 0 || repoStat.CustomProperties == nil {
+		return
+	}
+	values := []string{host, repoStat.Name, archived}
+	for _, property := range c.Properties {
+		values = append(values, repoStat.CustomProperties[property])
+	}
+	ch <- prometheus.MustNewConstMetric(c.propertiesMetric().desc, prometheus.GaugeValue, 1, values...)
+}
 
-	repoStats, err := c.Client.GetRepoStats(context.Background(), c.Users, c.Repos)
+func collectCompliance(ch chan<- prometheus.Metric, host string, repoStat github.RepoStats, protection github.BranchProtection, archived string) {
+	rules := map[string]bool{
+		"protected":              protection.Protected,
+		"required_reviews":       protection.RequiredReviews,
+		"required_status_checks": protection.RequiredStatusChecks,
+		"signed_commits":         protection.SignedCommits,
+		"force_push_allowed":     protection.ForcePushAllowed,
+	}
+	for rule, enabled := range rules {
+		ch <- prometheus.MustNewConstMetric(metrics["branch_protection"].desc, prometheus.GaugeValue, bool2float(enabled), host, repoStat.Name, archived, repoStat.DefaultBranch, rule)
+	}
+	settings := map[string]bool{
+		"delete_branch_on_merge": repoStat.DeleteBranchOnMerge,
+		"secret_scanning":        repoStat.SecretScanning,
+	}
+	for setting, enabled := range settings {
+		ch <- prometheus.MustNewConstMetric(metrics["repo_setting"].desc, prometheus.GaugeValue, bool2float(enabled), host, repoStat.Name, archived, setting)
+	}
+}
+
+// getStats returns the cached statistics, refreshing them if they are older than the refresh interval. Only one refresh
+// runs at a time. The lock is not held during the refresh, so Update and the health metrics don't wait for it.
+func (c *Collector) getStats(ctx context.Context) (map[string][]github.RepoStats, error) {
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	span := trace.SpanFromContext(ctx)
+	c.lock.RLock()
+	cache, fresh := c.cache, time.Since(c.lastUpdate) < c.refreshInterval()
+	c.lock.RUnlock()
+	if fresh {
+		span.SetAttributes(attribute.String("github_exporter.cache", "hit"))
+		return cache, nil
+	}
+	span.SetAttributes(attribute.String("github_exporter.cache", "miss"))
+
+	ctx, span = tracer.Start(ctx, "refresh")
+	defer span.End()
+
+	// the sources have their own clients and limiters, so refresh them in parallel
+	start := time.Now()
+	results := make([]refreshResult, len(c.Sources))
+	var wg sync.WaitGroup
+	for i, source := range c.Sources {
+		wg.Go(func() { results[i] = c.refresh(ctx, source) })
+	}
+	wg.Wait()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// Collect may still be reading the current cache, so replace it rather than update it
+	cache = make(map[string][]github.RepoStats, len(c.Sources))
+	var errs []error
+	var repos int
+	var interval time.Duration
+	for i, source := range c.Sources {
+		result := results[i]
+		repos += len(result.repoStats)
+		cache[source.id()] = c.cache[source.id()]
+		if result.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.id(), result.err))
+		} else {
+			cache[source.id()] = result.repoStats
+		}
+		c.observeQuotas(source, result.after)
+		if c.Adaptive != nil {
+			// each source has its own quota: refresh at the interval of the most constrained source
+			if sourceInterval, ok := c.Adaptive.interval(result.before, result.after, c.Lifetime, time.Now()); ok {
+				interval = max(interval, sourceInterval)
+			}
+		}
+	}
+	if interval > 0 {
+		c.interval = interval
+		c.Logger.Debug("refresh interval updated", "interval", interval)
+	}
+	err := errors.Join(errs...)
+	c.health.observe(c.Sources, results, time.Since(start))
+	c.cache = cache
 	if err == nil {
-		c.cache = repoStats
 		c.lastUpdate = time.Now()
 	}
+	span.SetAttributes(attribute.Int("github_exporter.repos", repos))
 
 	return c.cache, err
 }
 
+type refreshResult struct {
+	err       error
+	repoStats []github.RepoStats
+	// before and after hold the API quota before and after the refresh.
+	before []github.Quota
+	after  []github.Quota
+}
+
+func (c *Collector) refresh(ctx context.Context, source Source) refreshResult {
+	var result refreshResult
+	if c.Adaptive != nil {
+		result.before = c.getQuotas(ctx, source)
+	}
+	result.repoStats, result.err = source.Client.GetRepoStats(ctx, source.Users, source.Repos)
+	result.after = c.getQuotas(ctx, source)
+	return result
+}
+
+// Update applies an update to the cached statistics of a repo, identified by its host and full name <owner>/<repo>, e.g.
+// when a webhook event is received. The update is applied to each source of the host that collects the repo. The next
+// refresh replaces the cache, so updates only need to be approximately right. Update returns false if the repo is not
+// in the cache.
+func (c *Collector) Update(host string, repo string, update func(*github.RepoStats)) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var cache map[string][]github.RepoStats
+	for _, source := range c.Sources {
+		if source.Host != host {
+			continue
+		}
+		i := slices.IndexFunc(c.cache[source.id()], func(repoStats github.RepoStats) bool { return strings.EqualFold(repoStats.FullName, repo) })
+		if i < 0 {
+			continue
+		}
+		// Collect may still be reading the current cache, so update a copy
+		if cache == nil {
+			cache = maps.Clone(c.cache)
+		}
+		cache[source.id()] = slices.Clone(cache[source.id()])
+		update(&cache[source.id()][i])
+	}
+	if cache == nil {
+		return false
+	}
+	c.cache = cache
+	return true
+}
+
+// refreshInterval returns the time between refreshes. The caller must hold the lock.
+func (c *Collector) refreshInterval() time.Duration {
+	if c.interval > 0 {
+		return c.interval
+	}
+	return c.Lifetime
+}
+
+// getQuotas returns the API quota of a source. Querying the quota does not count against the quota.
+func (c *Collector) getQuotas(ctx context.Context, source Source) []github.Quota {
+	if source.Quotas == nil {
+		return nil
+	}
+	reported, err := source.Quotas.GetQuotas(ctx)
+	if err != nil {
+		c.Logger.Warn("failed to get github api quota", "source", source.id(), "err", err)
+	}
+	return reported
+}
+
+// observeQuotas records the API quota of a source after a refresh. The caller must hold the lock.
+func (c *Collector) observeQuotas(source Source, reported []github.Quota) {
+	if c.quotas == nil {
+		c.quotas = make(map[string]quotas)
+	}
+	if c.quotas[source.id()] == nil {
+		c.quotas[source.id()] = make(quotas)
+	}
+	c.quotas[source.id()].observe(reported, time.Now())
+}
+
+func (c *Collector) collectHealth(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	c.health.collect(ch)
+	for _, source := range c.Sources {
+		c.quotas[source.id()].collect(ch, source.Host, source.id())
+	}
+	ch <- prometheus.MustNewConstMetric(metrics["refresh_interval"].desc, prometheus.GaugeValue, c.refreshInterval().Seconds())
+}
+
+func bool2float(val bool) float64 {
+	if val {
+		return 1
+	}
+	return 0
+}
+
 func bool2string(val bool) string {
 	booleans := map[bool]string{
 		true:  "true",
@@ -3,14 +3,24 @@ package collector_test
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/clambin/github-exporter/internal/collector"
+	"github.com/clambin/github-exporter/internal/stats"
 	"github.com/clambin/github-exporter/internal/stats/github"
+	gogithub "github.com/google/go-github/v89/github"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestCollector_Collect(t *testing.T) {
@@ -45,24 +55,24 @@ func TestCollector_Collect(t *testing.T) {
 			want: `
 # HELP github_exporter_forks Total number of forks
 # TYPE github_exporter_forks gauge
-github_exporter_forks{archived="false",repo="clambin/github-exporter"} 1
-github_exporter_forks{archived="false",repo="clambin/tado-exporter"} 2
-github_exporter_forks{archived="true",repo="foo/bar"} 3
+github_exporter_forks{archived="false",host="github.com",repo="clambin/github-exporter"} 1
+github_exporter_forks{archived="false",host="github.com",repo="clambin/tado-exporter"} 2
+github_exporter_forks{archived="true",host="github.com",repo="foo/bar"} 3
 # HELP github_exporter_issues Total number of open issues
 # TYPE github_exporter_issues gauge
-github_exporter_issues{archived="false",repo="clambin/github-exporter"} 15
-github_exporter_issues{archived="false",repo="clambin/tado-exporter"} 25
-github_exporter_issues{archived="true",repo="foo/bar"} 5
+github_exporter_issues{archived="false",host="github.com",repo="clambin/github-exporter"} 15
+github_exporter_issues{archived="false",host="github.com",repo="clambin/tado-exporter"} 25
+github_exporter_issues{archived="true",host="github.com",repo="foo/bar"} 5
 # HELP github_exporter_pulls Total number of open pull requests
 # TYPE github_exporter_pulls gauge
-github_exporter_pulls{archived="false",repo="clambin/github-exporter"} 5
-github_exporter_pulls{archived="false",repo="clambin/tado-exporter"} 15
-github_exporter_pulls{archived="true",repo="foo/bar"} 5
+github_exporter_pulls{archived="false",host="github.com",repo="clambin/github-exporter"} 5
+github_exporter_pulls{archived="false",host="github.com",repo="clambin/tado-exporter"} 15
+github_exporter_pulls{archived="true",host="github.com",repo="foo/bar"} 5
 # HELP github_exporter_stars Total number of stars
 # TYPE github_exporter_stars gauge
-github_exporter_stars{archived="false",repo="clambin/github-exporter"} 10
-github_exporter_stars{archived="false",repo="clambin/tado-exporter"} 15
-github_exporter_stars{archived="true",repo="foo/bar"} 5
+github_exporter_stars{archived="false",host="github.com",repo="clambin/github-exporter"} 10
+github_exporter_stars{archived="false",host="github.com",repo="clambin/tado-exporter"} 15
+github_exporter_stars{archived="true",host="github.com",repo="foo/bar"} 5
 `,
 		},
 		{
@@ -83,21 +93,103 @@ github_exporter_stars{archived="true",repo="foo/bar"} 5
 			want: `
 		# HELP github_exporter_forks Total number of forks
 		# TYPE github_exporter_forks gauge
-		github_exporter_forks{archived="false",repo="clambin/github-exporter"} 1
-		github_exporter_forks{archived="false",repo="clambin/tado-exporter"} 2
+		github_exporter_forks{archived="false",host="github.com",repo="clambin/github-exporter"} 1
+		github_exporter_forks{archived="false",host="github.com",repo="clambin/tado-exporter"} 2
 		# HELP github_exporter_issues Total number of open issues
 		# TYPE github_exporter_issues gauge
-		github_exporter_issues{archived="false",repo="clambin/github-exporter"} 15
-		github_exporter_issues{archived="false",repo="clambin/tado-exporter"} 25
+		github_exporter_issues{archived="false",host="github.com",repo="clambin/github-exporter"} 15
+		github_exporter_issues{archived="false",host="github.com",repo="clambin/tado-exporter"} 25
 		# HELP github_exporter_pulls Total number of open pull requests
 		# TYPE github_exporter_pulls gauge
-		github_exporter_pulls{archived="false",repo="clambin/github-exporter"} 5
-		github_exporter_pulls{archived="false",repo="clambin/tado-exporter"} 15
+		github_exporter_pulls{archived="false",host="github.com",repo="clambin/github-exporter"} 5
+		github_exporter_pulls{archived="false",host="github.com",repo="clambin/tado-exporter"} 15
 		# HELP github_exporter_stars Total number of stars
 		# TYPE github_exporter_stars gauge
-		github_exporter_stars{archived="false",repo="clambin/github-exporter"} 10
-		github_exporter_stars{archived="false",repo="clambin/tado-exporter"} 15
+		github_exporter_stars{archived="false",host="github.com",repo="clambin/github-exporter"} 10
+		github_exporter_stars{archived="false",host="github.com",repo="clambin/tado-exporter"} 15
 		`,
+		},
+		{
+			name: "with activity",
+			statsClient: fakeStatsClient{
+				stats: []github.RepoStats{
+					{Name: "clambin/github-exporter", Stars: 10, Issues: 15, PullRequests: 5, Forks: 1, Activity: &github.Activity{
+						Commits: 5, Additions: 100, Deletions: 10, Contributors4w: 1, Contributors52w: 3,
+					}},
+				},
+			},
+			args: args{
+				repos: []string{"clambin/github-exporter"},
+			},
+			wantErr: assert.NoError,
+			want: `
+# HELP github_exporter_contributors Number of distinct contributors that committed in the window
+# TYPE github_exporter_contributors gauge
+github_exporter_contributors{archived="false",host="github.com",repo="clambin/github-exporter",window="4w"} 1
+github_exporter_contributors{archived="false",host="github.com",repo="clambin/github-exporter",window="52w"} 3
+# HELP github_exporter_forks Total number of forks
+# TYPE github_exporter_forks gauge
+github_exporter_forks{archived="false",host="github.com",repo="clambin/github-exporter"} 1
+# HELP github_exporter_issues Total number of open issues
+# TYPE github_exporter_issues gauge
+github_exporter_issues{archived="false",host="github.com",repo="clambin/github-exporter"} 15
+# HELP github_exporter_pulls Total number of open pull requests
+# TYPE github_exporter_pulls gauge
+github_exporter_pulls{archived="false",host="github.com",repo="clambin/github-exporter"} 5
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="github.com",repo="clambin/github-exporter"} 10
+# HELP github_exporter_weekly_additions Number of lines added in the last complete week
+# TYPE github_exporter_weekly_additions gauge
+github_exporter_weekly_additions{archived="false",host="github.com",repo="clambin/github-exporter"} 100
+# HELP github_exporter_weekly_commits Number of commits in the last complete week
+# TYPE github_exporter_weekly_commits gauge
+github_exporter_weekly_commits{archived="false",host="github.com",repo="clambin/github-exporter"} 5
+# HELP github_exporter_weekly_deletions Number of lines deleted in the last complete week
+# TYPE github_exporter_weekly_deletions gauge
+github_exporter_weekly_deletions{archived="false",host="github.com",repo="clambin/github-exporter"} 10
+`,
+		},
+		{
+			name: "with compliance",
+			statsClient: fakeStatsClient{
+				stats: []github.RepoStats{
+					{
+						Name: "clambin/github-exporter", Stars: 10, Issues: 15, PullRequests: 5, Forks: 1,
+						DefaultBranch: "main", SecretScanning: true,
+						BranchProtection: &github.BranchProtection{Protected: true, RequiredReviews: true},
+					},
+				},
+			},
+			args: args{
+				repos: []string{"clambin/github-exporter"},
+			},
+			wantErr: assert.NoError,
+			want: `
+# HELP github_exporter_branch_protection Protection rule of the default branch is enabled (1) or not (0)
+# TYPE github_exporter_branch_protection gauge
+github_exporter_branch_protection{archived="false",branch="main",host="github.com",repo="clambin/github-exporter",rule="force_push_allowed"} 0
+github_exporter_branch_protection{archived="false",branch="main",host="github.com",repo="clambin/github-exporter",rule="protected"} 1
+github_exporter_branch_protection{archived="false",branch="main",host="github.com",repo="clambin/github-exporter",rule="required_reviews"} 1
+github_exporter_branch_protection{archived="false",branch="main",host="github.com",repo="clambin/github-exporter",rule="required_status_checks"} 0
+github_exporter_branch_protection{archived="false",branch="main",host="github.com",repo="clambin/github-exporter",rule="signed_commits"} 0
+# HELP github_exporter_forks Total number of forks
+# TYPE github_exporter_forks gauge
+github_exporter_forks{archived="false",host="github.com",repo="clambin/github-exporter"} 1
+# HELP github_exporter_issues Total number of open issues
+# TYPE github_exporter_issues gauge
+github_exporter_issues{archived="false",host="github.com",repo="clambin/github-exporter"} 15
+# HELP github_exporter_pulls Total number of open pull requests
+# TYPE github_exporter_pulls gauge
+github_exporter_pulls{archived="false",host="github.com",repo="clambin/github-exporter"} 5
+# HELP github_exporter_repo_setting Repo setting is enabled (1) or not (0)
+# TYPE github_exporter_repo_setting gauge
+github_exporter_repo_setting{archived="false",host="github.com",repo="clambin/github-exporter",setting="delete_branch_on_merge"} 0
+github_exporter_repo_setting{archived="false",host="github.com",repo="clambin/github-exporter",setting="secret_scanning"} 1
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="github.com",repo="clambin/github-exporter"} 10
+`,
 		},
 		{
 			name: "failure",
@@ -117,19 +209,35 @@ github_exporter_stars{archived="true",repo="foo/bar"} 5
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := collector.Collector{
-				Client:          tt.statsClient,
-				Users:           tt.args.users,
-				Repos:           tt.args.repos,
+				Sources: []collector.Source{{
+					Host:   "github.com",
+					Client: tt.statsClient,
+					Users:  tt.args.users,
+					Repos:  tt.args.repos,
+				}},
 				IncludeArchived: tt.args.includeArchived,
 				Lifetime:        time.Second,
 				Logger:          slog.Default(),
 			}
-			tt.wantErr(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(tt.want)))
-			tt.wantErr(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(tt.want)))
+			tt.wantErr(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(tt.want), repoMetrics...))
+			tt.wantErr(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(tt.want), repoMetrics...))
 		})
 	}
 }
 
+var repoMetrics = []string{
+	"github_exporter_branch_protection",
+	"github_exporter_contributors",
+	"github_exporter_forks",
+	"github_exporter_issues",
+	"github_exporter_pulls",
+	"github_exporter_repo_setting",
+	"github_exporter_stars",
+	"github_exporter_weekly_additions",
+	"github_exporter_weekly_commits",
+	"github_exporter_weekly_deletions",
+}
+
 var _ collector.StatClient = fakeStatsClient{}
 
 type fakeStatsClient struct {
@@ -140,3 +248,578 @@ type fakeStatsClient struct {
 func (f fakeStatsClient) GetRepoStats(ctx context.Context, strings []string, strings2 []string) ([]github.RepoStats, error) {
 	return f.stats, f.err
 }
+
+func TestCollector_Collect_Info(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host: "github.com",
+			Client: fakeStatsClient{
+				stats: []github.RepoStats{{
+					Name:          "clambin/github-exporter",
+					Language:      "Go",
+					DefaultBranch: "main",
+					Visibility:    "public",
+					License:       "MIT",
+					Topics:        []string{"prometheus", "github"},
+					Size:          10240,
+					Watchers:      3,
+					CreatedAt:     time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+				}},
+			},
+			Repos: []string{"clambin/github-exporter"},
+		}},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_created_timestamp_seconds Time when the repo was created
+# TYPE github_exporter_created_timestamp_seconds gauge
+github_exporter_created_timestamp_seconds{archived="false",host="github.com",repo="clambin/github-exporter"} 1.5778368e+09
+# HELP github_exporter_repo_info Repo metadata
+# TYPE github_exporter_repo_info gauge
+github_exporter_repo_info{archived="false",default_branch="main",fork="false",host="github.com",language="Go",license="MIT",repo="clambin/github-exporter",template="false",topics="github,prometheus",visibility="public"} 1
+# HELP github_exporter_size_bytes Size of the repo
+# TYPE github_exporter_size_bytes gauge
+github_exporter_size_bytes{archived="false",host="github.com",repo="clambin/github-exporter"} 10240
+# HELP github_exporter_watchers Total number of watchers
+# TYPE github_exporter_watchers gauge
+github_exporter_watchers{archived="false",host="github.com",repo="clambin/github-exporter"} 3
+`),
+		"github_exporter_created_timestamp_seconds", "github_exporter_pushed_timestamp_seconds", "github_exporter_updated_timestamp_seconds",
+		"github_exporter_repo_info", "github_exporter_size_bytes", "github_exporter_watchers",
+	))
+}
+
+func TestCollector_Collect_Teams(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host: "github.com",
+			Client: fakeStatsClient{
+				stats: []github.RepoStats{
+					{Name: "foo/bar", Topics: []string{"team-payments", "go", "team-platform", "team-"}},
+					{Name: "foo/snafu", Topics: []string{"go"}, Teams: []github.TeamAccess{
+						{Org: "foo", Team: "platform", Permission: "write"},
+						{Org: "bar", Team: "platform", Permission: "read"},
+					}},
+				},
+			},
+			Users: []string{"foo"},
+		}},
+		TeamTopicPrefix: "team-",
+		Lifetime:        time.Hour,
+		Logger:          slog.Default(),
+	}
+
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_repo_team Team that has access to the repo. Teams derived from the repo's topics have no org and no permission
+# TYPE github_exporter_repo_team gauge
+github_exporter_repo_team{archived="false",host="github.com",org="",permission="",repo="foo/bar",team="payments"} 1
+github_exporter_repo_team{archived="false",host="github.com",org="",permission="",repo="foo/bar",team="platform"} 1
+github_exporter_repo_team{archived="false",host="github.com",org="bar",permission="read",repo="foo/snafu",team="platform"} 1
+github_exporter_repo_team{archived="false",host="github.com",org="foo",permission="write",repo="foo/snafu",team="platform"} 1
+`), "github_exporter_repo_team"))
+
+	c.TeamTopicPrefix = ""
+	assert.Equal(t, 2, testutil.CollectAndCount(&c, "github_exporter_repo_team"))
+}
+
+func TestCollector_Collect_Properties(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host: "github.com",
+			Client: fakeStatsClient{
+				stats: []github.RepoStats{
+					{Name: "foo/bar", CustomProperties: map[string]string{"service-tier": "1", "cost-center": "payments"}},
+					{Name: "foo/snafu", CustomProperties: map[string]string{"service-tier": "2"}},
+				},
+			},
+			Users: []string{"foo"},
+		}},
+		Properties: []string{"service-tier", "cost-center"},
+		Lifetime:   time.Hour,
+		Logger:     slog.Default(),
+	}
+
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_repo_properties Custom properties of the repo
+# TYPE github_exporter_repo_properties gauge
+github_exporter_repo_properties{archived="false",cost_center="payments",host="github.com",repo="foo/bar",service_tier="1"} 1
+github_exporter_repo_properties{archived="false",cost_center="",host="github.com",repo="foo/snafu",service_tier="2"} 1
+`), "github_exporter_repo_properties"))
+}
+
+func TestCollector_Collect_PullRequests(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host: "github.com",
+			Client: fakeStatsClient{
+				stats: []github.RepoStats{{
+					Name: "clambin/github-exporter",
+					PullRequestStats: &github.PullRequestStats{
+						TimeToFirstReview: []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour},
+						TimeToMerge:       []time.Duration{time.Hour, 5 * time.Hour},
+						Lines:             []int{30, 10, 20},
+						Merged:            2,
+						Unmerged:          1,
+					},
+				}},
+			},
+			Repos: []string{"clambin/github-exporter"},
+		}},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_closed_pulls Number of pull requests closed in the rolling window
+# TYPE github_exporter_closed_pulls gauge
+github_exporter_closed_pulls{archived="false",host="github.com",repo="clambin/github-exporter",state="merged"} 2
+github_exporter_closed_pulls{archived="false",host="github.com",repo="clambin/github-exporter",state="unmerged"} 1
+# HELP github_exporter_pull_size_lines Number of lines changed by a pull request, for pull requests closed in the rolling window
+# TYPE github_exporter_pull_size_lines summary
+github_exporter_pull_size_lines{archived="false",host="github.com",repo="clambin/github-exporter",quantile="0.5"} 20
+github_exporter_pull_size_lines{archived="false",host="github.com",repo="clambin/github-exporter",quantile="0.9"} 30
+github_exporter_pull_size_lines_sum{archived="false",host="github.com",repo="clambin/github-exporter"} 60
+github_exporter_pull_size_lines_count{archived="false",host="github.com",repo="clambin/github-exporter"} 3
+# HELP github_exporter_pull_time_to_first_review_seconds Time from opening a pull request to its first review, for pull requests closed in the rolling window
+# TYPE github_exporter_pull_time_to_first_review_seconds summary
+github_exporter_pull_time_to_first_review_seconds{archived="false",host="github.com",repo="clambin/github-exporter",quantile="0.5"} 7200
+github_exporter_pull_time_to_first_review_seconds{archived="false",host="github.com",repo="clambin/github-exporter",quantile="0.9"} 10800
+github_exporter_pull_time_to_first_review_seconds_sum{archived="false",host="github.com",repo="clambin/github-exporter"} 21600
+github_exporter_pull_time_to_first_review_seconds_count{archived="false",host="github.com",repo="clambin/github-exporter"} 3
+# HELP github_exporter_pull_time_to_merge_seconds Time from opening a pull request to merging it, for pull requests merged in the rolling window
+# TYPE github_exporter_pull_time_to_merge_seconds summary
+github_exporter_pull_time_to_merge_seconds{archived="false",host="github.com",repo="clambin/github-exporter",quantile="0.5"} 3600
+github_exporter_pull_time_to_merge_seconds{archived="false",host="github.com",repo="clambin/github-exporter",quantile="0.9"} 18000
+github_exporter_pull_time_to_merge_seconds_sum{archived="false",host="github.com",repo="clambin/github-exporter"} 21600
+github_exporter_pull_time_to_merge_seconds_count{archived="false",host="github.com",repo="clambin/github-exporter"} 2
+`),
+		"github_exporter_closed_pulls", "github_exporter_pull_size_lines",
+		"github_exporter_pull_time_to_first_review_seconds", "github_exporter_pull_time_to_merge_seconds",
+	))
+}
+
+func TestCollector_Collect_Sources(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{
+			{Host: "github.com", Client: fakeStatsClient{stats: []github.RepoStats{{Name: "bar", FullName: "foo/bar", Stars: 1}}}, Users: []string{"foo"}},
+			{Host: "github.example.com", Client: fakeStatsClient{stats: []github.RepoStats{{Name: "bar", FullName: "foo/bar", Stars: 2}}}, Users: []string{"foo"}},
+		},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="github.com",repo="bar"} 1
+github_exporter_stars{archived="false",host="github.example.com",repo="bar"} 2
+`), "github_exporter_stars"))
+
+	// updates only apply to the repo of the host
+	assert.True(t, c.Update("github.example.com", "foo/bar", func(repoStats *github.RepoStats) { repoStats.Stars++ }))
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="github.com",repo="bar"} 1
+github_exporter_stars{archived="false",host="github.example.com",repo="bar"} 3
+`), "github_exporter_stars"))
+}
+
+func TestCollector_Collect_Sources_Error(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{
+			{Host: "github.com", Client: fakeStatsClient{stats: []github.RepoStats{{Name: "bar", Stars: 1}}}, Users: []string{"foo"}},
+			{Host: "github.example.com", Client: fakeStatsClient{err: errors.New("failed")}, Users: []string{"foo"}},
+		},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+	err := testutil.CollectAndCompare(&c, bytes.NewBufferString(``), "github_exporter_stars")
+	assert.ErrorContains(t, err, "github.example.com: failed")
+}
+
+func TestCollector_Collect_Sources_SameHost(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	c := collector.Collector{
+		Sources: []collector.Source{
+			{
+				Name:   "public",
+				Host:   "github.com",
+				Client: fakeStatsClient{stats: []github.RepoStats{{Name: "foo/bar", FullName: "foo/bar", Stars: 1}}},
+				Quotas: fakeQuotaClient{quotas: []github.Quota{{Resource: "core", Limit: 5000, Reset: reset}}},
+				Users:  []string{"foo"},
+			},
+			{
+				Name:   "private",
+				Host:   "github.com",
+				Client: fakeStatsClient{stats: []github.RepoStats{{Name: "bar/foo", FullName: "bar/foo", Stars: 2}}},
+				Quotas: fakeQuotaClient{quotas: []github.Quota{{Resource: "core", Limit: 15000, Reset: reset}}},
+				Users:  []string{"bar"},
+			},
+		},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+
+	// each source has its own cache and quota
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_rate_limit Maximum number of GitHub API requests in the current rate limit window
+# TYPE github_exporter_rate_limit gauge
+github_exporter_rate_limit{host="github.com",resource="core",source="private"} 15000
+github_exporter_rate_limit{host="github.com",resource="core",source="public"} 5000
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="github.com",repo="bar/foo"} 2
+github_exporter_stars{archived="false",host="github.com",repo="foo/bar"} 1
+`), "github_exporter_rate_limit", "github_exporter_stars"))
+
+	assert.True(t, c.Update("github.com", "bar/foo", func(repoStats *github.RepoStats) { repoStats.Stars++ }))
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="github.com",repo="bar/foo"} 3
+github_exporter_stars{archived="false",host="github.com",repo="foo/bar"} 1
+`), "github_exporter_stars"))
+}
+
+func TestCollector_Update(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host: "github.com",
+			Client: fakeStatsClient{stats: []github.RepoStats{
+				{Name: "a/api", FullName: "a/api", Stars: 1},
+				{Name: "b/api", FullName: "b/api", Stars: 1},
+			}},
+			Users: []string{"a", "b"},
+		}},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+	// nothing cached yet
+	assert.False(t, c.Update("github.com", "a/api", func(repoStats *github.RepoStats) { repoStats.Stars++ }))
+
+	const want = `
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="github.com",repo="a/api"} %d
+github_exporter_stars{archived="false",host="github.com",repo="b/api"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(fmt.Sprintf(want, 1)), "github_exporter_stars"))
+	// updates match the full name, case-insensitively
+	assert.True(t, c.Update("github.com", "A/API", func(repoStats *github.RepoStats) { repoStats.Stars++ }))
+	assert.False(t, c.Update("github.com", "a/snafu", func(repoStats *github.RepoStats) { repoStats.Stars++ }))
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(fmt.Sprintf(want, 2)), "github_exporter_stars"))
+}
+
+func TestCollector_Update_DuringRefresh(t *testing.T) {
+	client := blockingStatsClient{
+		stats:   []github.RepoStats{{Name: "bar", FullName: "foo/bar", Stars: 1}},
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+	c := collector.Collector{
+		Sources:  []collector.Source{{Host: "github.com", Client: client, Repos: []string{"foo/bar"}}},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+	go func() { _ = testutil.CollectAndCount(&c) }()
+	<-client.started
+
+	// the refresh is still running, but updates don't wait for it
+	updated := make(chan bool)
+	go func() {
+		updated <- c.Update("github.com", "foo/bar", func(repoStats *github.RepoStats) { repoStats.Stars++ })
+	}()
+	select {
+	case ok := <-updated:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Error("update blocked by the refresh")
+	}
+	close(client.release)
+}
+
+// blockingStatsClient signals started when a refresh starts and blocks it until release is closed.
+type blockingStatsClient struct {
+	stats   []github.RepoStats
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f blockingStatsClient) GetRepoStats(context.Context, []string, []string) ([]github.RepoStats, error) {
+	select {
+	case f.started <- struct{}{}:
+	default:
+	}
+	<-f.release
+	return f.stats, nil
+}
+
+func TestCollector_Collect_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host:   "github.com",
+			Client: fakeStatsClient{stats: []github.RepoStats{{Name: "foo/bar"}}},
+			Repos:  []string{"foo/bar"},
+		}},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+	_ = testutil.CollectAndCount(&c)
+	_ = testutil.CollectAndCount(&c)
+
+	var cacheStatus []string
+	var refreshes int
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "collect":
+			for _, attr := range span.Attributes() {
+				if attr.Key == "github_exporter.cache" {
+					cacheStatus = append(cacheStatus, attr.Value.AsString())
+				}
+			}
+		case "refresh":
+			refreshes++
+		}
+	}
+	assert.Equal(t, []string{"miss", "hit"}, cacheStatus)
+	assert.Equal(t, 1, refreshes)
+}
+
+func TestCollector_Collect_Quotas(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host:   "github.com",
+			Client: fakeStatsClient{stats: []github.RepoStats{{Name: "foo/bar"}}},
+			Quotas: fakeQuotaClient{quotas: []github.Quota{{Resource: "core", Limit: 5000, Remaining: 4000, Used: 1000, Reset: reset}}},
+			Repos:  []string{"foo/bar"},
+		}},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(fmt.Sprintf(`
+# HELP github_exporter_rate_limit Maximum number of GitHub API requests in the current rate limit window
+# TYPE github_exporter_rate_limit gauge
+github_exporter_rate_limit{host="github.com",resource="core",source="github.com"} 5000
+# HELP github_exporter_rate_limit_remaining Number of GitHub API requests remaining in the current rate limit window
+# TYPE github_exporter_rate_limit_remaining gauge
+github_exporter_rate_limit_remaining{host="github.com",resource="core",source="github.com"} 4000
+# HELP github_exporter_rate_limit_reset_timestamp_seconds Time at which the current rate limit window resets
+# TYPE github_exporter_rate_limit_reset_timestamp_seconds gauge
+github_exporter_rate_limit_reset_timestamp_seconds{host="github.com",resource="core",source="github.com"} %d
+# HELP github_exporter_rate_limit_used Number of GitHub API requests used in the current rate limit window
+# TYPE github_exporter_rate_limit_used gauge
+github_exporter_rate_limit_used{host="github.com",resource="core",source="github.com"} 1000
+`, reset.Unix())),
+		"github_exporter_rate_limit",
+		"github_exporter_rate_limit_remaining",
+		"github_exporter_rate_limit_reset_timestamp_seconds",
+		"github_exporter_rate_limit_used",
+		"github_exporter_rate_limit_exhaustion_timestamp_seconds",
+	))
+
+	// a failing quota query doesn't fail the collection
+	c = collector.Collector{
+		Sources: []collector.Source{{
+			Host:   "github.com",
+			Client: fakeStatsClient{stats: []github.RepoStats{{Name: "foo/bar"}}},
+			Quotas: fakeQuotaClient{err: errors.New("failed")},
+			Repos:  []string{"foo/bar"},
+		}},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+	assert.Equal(t, 0, testutil.CollectAndCount(&c, "github_exporter_rate_limit"))
+	assert.Equal(t, 1, testutil.CollectAndCount(&c, "github_exporter_stars"))
+}
+
+var _ collector.QuotaClient = fakeQuotaClient{}
+
+type fakeQuotaClient struct {
+	err    error
+	quotas []github.Quota
+}
+
+func (f fakeQuotaClient) GetQuotas(context.Context) ([]github.Quota, error) {
+	return f.quotas, f.err
+}
+
+func TestCollector_Collect_Adaptive(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host:   "github.com",
+			Client: fakeStatsClient{stats: []github.RepoStats{{Name: "foo/bar"}}},
+			Quotas: &refreshCostQuotaClient{cost: 250, reset: time.Now().Add(30 * time.Minute)},
+			Repos:  []string{"foo/bar"},
+		}},
+		Adaptive: &collector.Adaptive{Budget: 0.5, MinInterval: time.Minute},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+
+	// a refresh costs 250 requests and the budget is 2500 requests per hour: refresh every 6 minutes
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_refresh_interval_seconds Time between refreshes
+# TYPE github_exporter_refresh_interval_seconds gauge
+github_exporter_refresh_interval_seconds 360
+`), "github_exporter_refresh_interval_seconds"))
+}
+
+var _ collector.QuotaClient = &refreshCostQuotaClient{}
+
+// refreshCostQuotaClient reports a core quota that uses cost requests between consecutive calls.
+type refreshCostQuotaClient struct {
+	reset time.Time
+	cost  int
+	used  int
+}
+
+func (f *refreshCostQuotaClient) GetQuotas(context.Context) ([]github.Quota, error) {
+	quota := github.Quota{Resource: "core", Limit: 5000, Remaining: 5000 - f.used, Used: f.used, Reset: f.reset}
+	f.used += f.cost
+	return []github.Quota{quota}, nil
+}
+
+func TestCollector_Collect_Health(t *testing.T) {
+	notFound := &gogithub.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host: "github.com",
+			Client: fakeStatsClient{
+				stats: []github.RepoStats{{Name: "bar", FullName: "foo/bar"}},
+				err: errors.Join(
+					&stats.RepoError{Repo: "foo/snafu", Err: notFound},
+					&stats.RepoError{Repo: "foo/timeout", Err: context.DeadlineExceeded},
+				),
+			},
+			Repos: []string{"foo/bar", "foo/snafu", "foo/timeout"},
+		}},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+
+	assert.Error(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_errors_total Total number of errors getting github statistics
+# TYPE github_exporter_errors_total counter
+github_exporter_errors_total{class="forbidden"} 0
+github_exporter_errors_total{class="not_found"} 1
+github_exporter_errors_total{class="other"} 0
+github_exporter_errors_total{class="rate_limited"} 0
+github_exporter_errors_total{class="timeout"} 1
+# HELP github_exporter_last_refresh_timestamp_seconds Time of the last successful refresh
+# TYPE github_exporter_last_refresh_timestamp_seconds gauge
+github_exporter_last_refresh_timestamp_seconds 0
+# HELP github_exporter_repos_monitored Number of repos found in the last refresh
+# TYPE github_exporter_repos_monitored gauge
+github_exporter_repos_monitored 3
+`), "github_exporter_errors_total", "github_exporter_last_refresh_timestamp_seconds", "github_exporter_repos_monitored"))
+
+	r := prometheus.NewPedanticRegistry()
+	r.MustRegister(&c)
+	mfs, _ := r.Gather()
+	var found bool
+	for _, mf := range mfs {
+		switch mf.GetName() {
+		case "github_exporter_repo_last_success_timestamp_seconds":
+			found = true
+			require.Len(t, mf.GetMetric(), 1)
+			assert.Equal(t, "github.com", mf.GetMetric()[0].GetLabel()[0].GetValue())
+			assert.Equal(t, "foo/bar", mf.GetMetric()[0].GetLabel()[1].GetValue())
+			assert.NotZero(t, mf.GetMetric()[0].GetGauge().GetValue())
+		case "github_exporter_refresh_duration_seconds":
+			assert.Equal(t, uint64(2), mf.GetMetric()[0].GetHistogram().GetSampleCount())
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestCollector_Collect_Health_ListError(t *testing.T) {
+	notFound := &gogithub.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host: "github.com",
+			Client: fakeStatsClient{
+				stats: []github.RepoStats{{Name: "foo/bar"}},
+				err:   &stats.RepoError{Repo: "foo/snafu", Err: notFound},
+			},
+			Users: []string{"foo"},
+		}},
+		Lifetime: time.Nanosecond,
+		Logger:   slog.Default(),
+	}
+	r := prometheus.NewRegistry()
+	r.MustRegister(&c)
+	assert.Equal(t, 2.0, gauge(t, r, "github_exporter_repos_monitored"))
+
+	// if the repos can't be listed, the previous number of repos is kept
+	c.Sources[0].Client = fakeStatsClient{err: errors.New("failed to list repos")}
+	assert.Equal(t, 2.0, gauge(t, r, "github_exporter_repos_monitored"))
+}
+
+func TestCollector_Collect_Health_RepoLastSuccess(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host: "github.com",
+			Client: fakeStatsClient{stats: []github.RepoStats{
+				{Name: "bar", FullName: "foo/bar"},
+				{Name: "bar", FullName: "snafu/bar"},
+			}},
+			Users: []string{"foo", "snafu"},
+		}},
+		Lifetime: time.Nanosecond,
+		Logger:   slog.Default(),
+	}
+	r := prometheus.NewRegistry()
+	r.MustRegister(&c)
+	// repos with the same name, but a different owner, are reported separately
+	assert.Equal(t, []string{"foo/bar", "snafu/bar"}, lastSuccessRepos(t, r))
+
+	// if the repos can't be listed, the repos are kept
+	c.Sources[0].Client = fakeStatsClient{err: errors.New("failed to list repos")}
+	assert.Equal(t, []string{"foo/bar", "snafu/bar"}, lastSuccessRepos(t, r))
+
+	// repos that are no longer monitored are removed
+	c.Sources[0].Client = fakeStatsClient{stats: []github.RepoStats{{Name: "bar", FullName: "foo/bar"}}}
+	assert.Equal(t, []string{"foo/bar"}, lastSuccessRepos(t, r))
+}
+
+// lastSuccessRepos returns the repo label of the repo_last_success metric.
+func lastSuccessRepos(t *testing.T, g prometheus.Gatherer) []string {
+	t.Helper()
+	mfs, _ := g.Gather()
+	var repos []string
+	for _, mf := range mfs {
+		if mf.GetName() != "github_exporter_repo_last_success_timestamp_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "repo" {
+					repos = append(repos, l.GetValue())
+				}
+			}
+		}
+	}
+	return repos
+}
+
+// gauge returns the value of a gauge without labels. The gauge is gathered even if the refresh fails.
+func gauge(t *testing.T, g prometheus.Gatherer, name string) float64 {
+	t.Helper()
+	mfs, _ := g.Gather()
+	for _, mf := range mfs {
+		if mf.GetName() == name {
+			require.Len(t, mf.GetMetric(), 1)
+			return mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("%s not found", name)
+	return 0
+}
@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Families selects the metric families that the Collector reports and limits their number of series. A family is
+// identified by its metric name, e.g. github_exporter_stars.
+type Families struct {
+	// MaxSeries limits the number of series reported for a family. The first series, ordered by their label values, are
+	// reported. Series beyond the limit are dropped and counted in github_exporter_series_dropped_total. Families without a limit report all their series.
+	MaxSeries map[string]int
+	// Enabled lists the reported families. If empty, all families are reported.
+	Enabled []string
+	// Disabled lists the families that are not reported, even if they are Enabled.
+	Disabled []string
+}
+
+// Reported returns true if the family is reported.
+func (f Families) Reported(family string) bool {
+	if slices.Contains(f.Disabled, family) {
+		return false
+	}
+	return len(f.Enabled) == 0 || slices.Contains(f.Enabled, family)
+}
+
+const (
+	propertiesFamily    = "github_exporter_repo_properties"
+	seriesDroppedFamily = "github_exporter_series_dropped_total"
+)
+
+// familyNames maps the description of each metric to its family.
+var familyNames = func() map[*prometheus.Desc]string {
+	names := make(map[*prometheus.Desc]string, len(metrics))
+	for _, metric := range metrics {
+		names[metric.desc] = metric.name
+	}
+	return names
+}()
+
+// FamilyNames returns the names of all metric families reported by the Collector, in alphabetical order.
+func FamilyNames() []string {
+	names := append(slices.Collect(maps.Values(familyNames)), propertiesFamily)
+	slices.Sort(names)
+	return names
+}
+
+// limit forwards the metrics of the reported families from in to out. names maps the description of each metric to its
+// family. Families with a MaxSeries are buffered until in is closed and report the first MaxSeries series, ordered by
+// their label values, so that the same series are kept at each collection. The number of dropped series of each family
+// is added to dropped. Metrics that don't belong to a family (e.g. the invalid metric reporting a failed refresh) are
+// always forwarded.
+func (f Families) limit(out chan<- prometheus.Metric, in <-chan prometheus.Metric, names map[*prometheus.Desc]string, dropped map[string]int) {
+	limited := make(map[string][]labeledMetric)
+	for metric := range in {
+		name, ok := names[metric.Desc()]
+		if !ok {
+			out <- metric
+			continue
+		}
+		if !f.Reported(name) {
+			continue
+		}
+		if _, ok := f.MaxSeries[name]; !ok {
+			out <- metric
+			continue
+		}
+		limited[name] = append(limited[name], newLabeledMetric(metric))
+	}
+
+	for name, series := range limited {
+		slices.SortFunc(series, func(a, b labeledMetric) int { return slices.Compare(a.labels, b.labels) })
+		maxSeries := min(f.MaxSeries[name], len(series))
+		for _, s := range series[:maxSeries] {
+			out <- s.metric
+		}
+		dropped[name] += len(series) - maxSeries
+	}
+}
+
+// labeledMetric is a metric with its label values, ordered by label name.
+type labeledMetric struct {
+	metric prometheus.Metric
+	labels []string
+}
+
+func newLabeledMetric(metric prometheus.Metric) labeledMetric {
+	var m dto.Metric
+	_ = metric.Write(&m)
+	labels := make([]string, 0, len(m.GetLabel()))
+	for _, label := range m.GetLabel() {
+		labels = append(labels, label.GetValue())
+	}
+	return labeledMetric{metric: metric, labels: labels}
+}
@@ -0,0 +1,121 @@
+package collector_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/collector"
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFamilies_Reported(t *testing.T) {
+	tests := []struct {
+		name     string
+		families collector.Families
+		family   string
+		want     bool
+	}{
+		{name: "default", family: "github_exporter_stars", want: true},
+		{name: "enabled", families: collector.Families{Enabled: []string{"github_exporter_stars"}}, family: "github_exporter_stars", want: true},
+		{name: "not enabled", families: collector.Families{Enabled: []string{"github_exporter_stars"}}, family: "github_exporter_forks", want: false},
+		{name: "disabled", families: collector.Families{Disabled: []string{"github_exporter_stars"}}, family: "github_exporter_stars", want: false},
+		{
+			name:     "enabled and disabled",
+			families: collector.Families{Enabled: []string{"github_exporter_stars"}, Disabled: []string{"github_exporter_stars"}},
+			family:   "github_exporter_stars",
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.families.Reported(tt.family))
+		})
+	}
+}
+
+func TestFamilyNames(t *testing.T) {
+	names := collector.FamilyNames()
+	assert.IsNonDecreasing(t, names)
+	assert.Contains(t, names, "github_exporter_stars")
+	assert.Contains(t, names, "github_exporter_repo_properties")
+	assert.Contains(t, names, "github_exporter_series_dropped_total")
+}
+
+func TestCollector_Collect_Families(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host: "github.com",
+			Client: fakeStatsClient{stats: []github.RepoStats{
+				{Name: "foo/bar", Stars: 1, Forks: 2, CustomProperties: map[string]string{"service-tier": "1"}},
+			}},
+			Users: []string{"foo"},
+		}},
+		Properties: []string{"service-tier"},
+		Families: collector.Families{
+			Enabled:  []string{"github_exporter_stars", "github_exporter_forks", "github_exporter_repo_properties"},
+			Disabled: []string{"github_exporter_forks"},
+		},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+
+	// the pedantic registry checks that only the described families are collected
+	r := prometheus.NewPedanticRegistry()
+	require.NoError(t, r.Register(&c))
+	mfs, err := r.Gather()
+	require.NoError(t, err)
+	var names []string
+	for _, mf := range mfs {
+		names = append(names, mf.GetName())
+	}
+	assert.Equal(t, []string{"github_exporter_repo_properties", "github_exporter_stars"}, names)
+}
+
+func TestCollector_Collect_MaxSeries(t *testing.T) {
+	c := collector.Collector{
+		Sources: []collector.Source{{
+			Host: "github.com",
+			Client: fakeStatsClient{stats: []github.RepoStats{
+				{Name: "foo/snafu2", Stars: 3, CustomProperties: map[string]string{"service-tier": "1"}},
+				{Name: "foo/snafu", Stars: 2, CustomProperties: map[string]string{"service-tier": "2"}},
+				{Name: "foo/bar", Stars: 1, CustomProperties: map[string]string{"service-tier": "3"}},
+			}},
+			Users: []string{"foo"},
+		}},
+		Properties: []string{"service-tier"},
+		Families: collector.Families{MaxSeries: map[string]int{
+			"github_exporter_stars":           1,
+			"github_exporter_forks":           5,
+			"github_exporter_repo_properties": 2,
+		}},
+		Lifetime: time.Hour,
+		Logger:   slog.Default(),
+	}
+
+	// the limited families keep the first series, ordered by their label values
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_repo_properties Custom properties of the repo
+# TYPE github_exporter_repo_properties gauge
+github_exporter_repo_properties{archived="false",host="github.com",repo="foo/bar",service_tier="3"} 1
+github_exporter_repo_properties{archived="false",host="github.com",repo="foo/snafu",service_tier="2"} 1
+# HELP github_exporter_stars Total number of stars
+# TYPE github_exporter_stars gauge
+github_exporter_stars{archived="false",host="github.com",repo="foo/bar"} 1
+`), "github_exporter_stars", "github_exporter_repo_properties"))
+	assert.Equal(t, 3, testutil.CollectAndCount(&c, "github_exporter_forks"))
+
+	// the dropped series are counted at each collection
+	assert.NoError(t, testutil.CollectAndCompare(&c, bytes.NewBufferString(`
+# HELP github_exporter_series_dropped_total Total number of series dropped because their family exceeded its maximum number of series
+# TYPE github_exporter_series_dropped_total counter
+github_exporter_series_dropped_total{family="github_exporter_forks"} 0
+github_exporter_series_dropped_total{family="github_exporter_repo_properties"} 3
+github_exporter_series_dropped_total{family="github_exporter_stars"} 6
+`), "github_exporter_series_dropped_total"))
+}
@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"errors"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats"
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// refreshBuckets are the buckets of the refresh duration histogram.
+var refreshBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600}
+
+// health records the outcome of each refresh, so the collector can report on its own health.
+type health struct {
+	lastRefresh     time.Time
+	repoLastSuccess map[repoKey]time.Time
+	errors          map[string]int
+	durationBuckets []uint64
+	durationSum     float64
+	durationCount   uint64
+	// reposMonitored holds the number of repos found in the last refresh of each source.
+	reposMonitored map[string]int
+}
+
+// repoKey identifies a repo across sources. repo is the repo's full name, i.e. <owner>/<name>.
+type repoKey struct {
+	host string
+	repo string
+}
+
+// observe records the outcome of a refresh. results holds the refresh result of each source.
+func (h *health) observe(sources []Source, results []refreshResult, duration time.Duration) {
+	if h.repoLastSuccess == nil {
+		h.repoLastSuccess = make(map[repoKey]time.Time)
+		h.errors = make(map[string]int)
+		h.durationBuckets = make([]uint64, len(refreshBuckets))
+		h.reposMonitored = make(map[string]int)
+	}
+
+	now := time.Now()
+	succeeded := true
+	// monitored holds the repos found in this refresh. unlisted holds the hosts of the sources whose repos couldn't be listed.
+	monitored := make(map[repoKey]struct{})
+	unlisted := make(map[string]bool)
+	for i, source := range sources {
+		for _, repoStat := range results[i].repoStats {
+			key := repoKey{host: source.Host, repo: repoStat.FullName}
+			h.repoLastSuccess[key] = now
+			monitored[key] = struct{}{}
+		}
+		// if the repos couldn't be listed, the number of repos is unknown: keep the previous number
+		if failed, ok := repoErrors(results[i].err); ok {
+			h.reposMonitored[source.id()] = len(results[i].repoStats) + len(failed)
+			for _, repo := range failed {
+				monitored[repoKey{host: source.Host, repo: repo}] = struct{}{}
+			}
+		} else {
+			unlisted[source.Host] = true
+		}
+		for _, e := range flatten(results[i].err) {
+			h.errors[github.ErrorClass(e)]++
+			succeeded = false
+		}
+	}
+	if succeeded {
+		h.lastRefresh = now
+	}
+	// forget the repos that are no longer monitored
+	for key := range h.repoLastSuccess {
+		if _, ok := monitored[key]; !ok && !unlisted[key.host] {
+			delete(h.repoLastSuccess, key)
+		}
+	}
+
+	seconds := duration.Seconds()
+	for i, bucket := range refreshBuckets {
+		if seconds <= bucket {
+			h.durationBuckets[i]++
+		}
+	}
+	h.durationSum += seconds
+	h.durationCount++
+}
+
+func (h *health) collect(ch chan<- prometheus.Metric) {
+	var lastRefresh float64
+	if !h.lastRefresh.IsZero() {
+		lastRefresh = float64(h.lastRefresh.Unix())
+	}
+	ch <- prometheus.MustNewConstMetric(metrics["last_refresh"].desc, prometheus.GaugeValue, lastRefresh)
+	var reposMonitored int
+	for _, repos := range h.reposMonitored {
+		reposMonitored += repos
+	}
+	ch <- prometheus.MustNewConstMetric(metrics["repos_monitored"].desc, prometheus.GaugeValue, float64(reposMonitored))
+
+	buckets := make(map[float64]uint64, len(refreshBuckets))
+	for i, bucket := range refreshBuckets {
+		var count uint64
+		if i < len(h.durationBuckets) {
+			count = h.durationBuckets[i]
+		}
+		buckets[bucket] = count
+	}
+	ch <- prometheus.MustNewConstHistogram(metrics["refresh_duration"].desc, h.durationCount, h.durationSum, buckets)
+
+	for key, lastSuccess := range h.repoLastSuccess {
+		ch <- prometheus.MustNewConstMetric(metrics["repo_last_success"].desc, prometheus.GaugeValue, float64(lastSuccess.Unix()), key.host, key.repo)
+	}
+	for _, class := range errorClasses {
+		ch <- prometheus.MustNewConstMetric(metrics["errors"].desc, prometheus.CounterValue, float64(h.errors[class]), class)
+	}
+}
+
+var errorClasses = []string{
+	github.ErrorClassNotFound,
+	github.ErrorClassRateLimited,
+	github.ErrorClassForbidden,
+	github.ErrorClassTimeout,
+	github.ErrorClassOther,
+}
+
+// repoErrors returns the full names of the repos that couldn't be retrieved. It returns false if err holds any other
+// errors, i.e. the repos couldn't be listed.
+func repoErrors(err error) ([]string, bool) {
+	var repos []string
+	for _, e := range flatten(err) {
+		var repoErr *stats.RepoError
+		if !errors.As(e, &repoErr) {
+			return nil, false
+		}
+		repos = append(repos, repoErr.Repo)
+	}
+	return repos, true
+}
+
+// flatten returns the individual errors held in a (possibly joined) error.
+func flatten(err error) []error {
+	if err == nil {
+		return nil
+	}
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, flatten(e)...)
+	}
+	return errs
+}
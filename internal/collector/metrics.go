@@ -2,29 +2,182 @@ package collector
 
 import "github.com/prometheus/client_golang/prometheus"
 
-var metrics = map[string]*prometheus.Desc{
-	"stars": prometheus.NewDesc(
-		prometheus.BuildFQName("github", "exporter", "stars"),
+// metric is a metric family reported by the Collector. name is the family's metric name, e.g. github_exporter_stars.
+type metric struct {
+	name string
+	desc *prometheus.Desc
+}
+
+// newMetric returns the github_exporter_<name> metric family.
+func newMetric(name string, help string, labels []string) metric {
+	fqName := prometheus.BuildFQName("github", "exporter", name)
+	return metric{name: fqName, desc: prometheus.NewDesc(fqName, help, labels, nil)}
+}
+
+var metrics = map[string]metric{
+	"stars": newMetric(
+		"stars",
 		"Total number of stars",
-		[]string{"repo", "archived"},
-		nil,
+		[]string{"host", "repo", "archived"},
 	),
-	"issues": prometheus.NewDesc(
-		prometheus.BuildFQName("github", "exporter", "issues"),
+	"issues": newMetric(
+		"issues",
 		"Total number of open issues",
-		[]string{"repo", "archived"},
-		nil,
+		[]string{"host", "repo", "archived"},
 	),
-	"pulls": prometheus.NewDesc(
-		prometheus.BuildFQName("github", "exporter", "pulls"),
+	"pulls": newMetric(
+		"pulls",
 		"Total number of open pull requests",
-		[]string{"repo", "archived"},
-		nil,
+		[]string{"host", "repo", "archived"},
 	),
-	"forks": prometheus.NewDesc(
-		prometheus.BuildFQName("github", "exporter", "forks"),
+	"forks": newMetric(
+		"forks",
 		"Total number of forks",
-		[]string{"repo", "archived"},
+		[]string{"host", "repo", "archived"},
+	),
+	"closed_pulls": newMetric(
+		"closed_pulls",
+		"Number of pull requests closed in the rolling window",
+		[]string{"host", "repo", "archived", "state"},
+	),
+	"pull_time_to_first_review": newMetric(
+		"pull_time_to_first_review_seconds",
+		"Time from opening a pull request to its first review, for pull requests closed in the rolling window",
+		[]string{"host", "repo", "archived"},
+	),
+	"pull_time_to_merge": newMetric(
+		"pull_time_to_merge_seconds",
+		"Time from opening a pull request to merging it, for pull requests merged in the rolling window",
+		[]string{"host", "repo", "archived"},
+	),
+	"pull_size": newMetric(
+		"pull_size_lines",
+		"Number of lines changed by a pull request, for pull requests closed in the rolling window",
+		[]string{"host", "repo", "archived"},
+	),
+	"repo_info": newMetric(
+		"repo_info",
+		"Repo metadata",
+		[]string{"host", "repo", "archived", "language", "default_branch", "visibility", "fork", "template", "license", "topics"},
+	),
+	"repo_team": newMetric(
+		"repo_team",
+		"Team that has access to the repo. Teams derived from the repo's topics have no org and no permission",
+		[]string{"host", "repo", "archived", "org", "team", "permission"},
+	),
+	"size": newMetric(
+		"size_bytes",
+		"Size of the repo",
+		[]string{"host", "repo", "archived"},
+	),
+	"watchers": newMetric(
+		"watchers",
+		"Total number of watchers",
+		[]string{"host", "repo", "archived"},
+	),
+	"created": newMetric(
+		"created_timestamp_seconds",
+		"Time when the repo was created",
+		[]string{"host", "repo", "archived"},
+	),
+	"pushed": newMetric(
+		"pushed_timestamp_seconds",
+		"Time of the last push to the repo",
+		[]string{"host", "repo", "archived"},
+	),
+	"updated": newMetric(
+		"updated_timestamp_seconds",
+		"Time of the last update of the repo",
+		[]string{"host", "repo", "archived"},
+	),
+	"weekly_commits": newMetric(
+		"weekly_commits",
+		"Number of commits in the last complete week",
+		[]string{"host", "repo", "archived"},
+	),
+	"weekly_additions": newMetric(
+		"weekly_additions",
+		"Number of lines added in the last complete week",
+		[]string{"host", "repo", "archived"},
+	),
+	"weekly_deletions": newMetric(
+		"weekly_deletions",
+		"Number of lines deleted in the last complete week",
+		[]string{"host", "repo", "archived"},
+	),
+	"contributors": newMetric(
+		"contributors",
+		"Number of distinct contributors that committed in the window",
+		[]string{"host", "repo", "archived", "window"},
+	),
+	"branch_protection": newMetric(
+		"branch_protection",
+		"Protection rule of the default branch is enabled (1) or not (0)",
+		[]string{"host", "repo", "archived", "branch", "rule"},
+	),
+	"repo_setting": newMetric(
+		"repo_setting",
+		"Repo setting is enabled (1) or not (0)",
+		[]string{"host", "repo", "archived", "setting"},
+	),
+	"last_refresh": newMetric(
+		"last_refresh_timestamp_seconds",
+		"Time of the last successful refresh",
 		nil,
 	),
+	"refresh_interval": newMetric(
+		"refresh_interval_seconds",
+		"Time between refreshes",
+		nil,
+	),
+	"refresh_duration": newMetric(
+		"refresh_duration_seconds",
+		"Duration of a refresh",
+		nil,
+	),
+	"repos_monitored": newMetric(
+		"repos_monitored",
+		"Number of repos found in the last refresh",
+		nil,
+	),
+	"repo_last_success": newMetric(
+		"repo_last_success_timestamp_seconds",
+		"Time of the last successful refresh of the repo. The repo label holds the repo's full name (owner/name)",
+		[]string{"host", "repo"},
+	),
+	"errors": newMetric(
+		"errors_total",
+		"Total number of errors getting github statistics",
+		[]string{"class"},
+	),
+	"series_dropped": newMetric(
+		"series_dropped_total",
+		"Total number of series dropped because their family exceeded its maximum number of series",
+		[]string{"family"},
+	),
+	"rate_limit": newMetric(
+		"rate_limit",
+		"Maximum number of GitHub API requests in the current rate limit window",
+		[]string{"host", "source", "resource"},
+	),
+	"rate_limit_remaining": newMetric(
+		"rate_limit_remaining",
+		"Number of GitHub API requests remaining in the current rate limit window",
+		[]string{"host", "source", "resource"},
+	),
+	"rate_limit_used": newMetric(
+		"rate_limit_used",
+		"Number of GitHub API requests used in the current rate limit window",
+		[]string{"host", "source", "resource"},
+	),
+	"rate_limit_reset": newMetric(
+		"rate_limit_reset_timestamp_seconds",
+		"Time at which the current rate limit window resets",
+		[]string{"host", "source", "resource"},
+	),
+	"rate_limit_exhaustion": newMetric(
+		"rate_limit_exhaustion_timestamp_seconds",
+		"Predicted time at which the rate limit runs out, at the consumption rate observed between refreshes. Only reported if it runs out before the window resets",
+		[]string{"host", "source", "resource"},
+	),
 }
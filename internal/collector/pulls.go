@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"slices"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pullRequestQuantiles are the quantiles reported for the pull requests closed in the rolling window.
+var pullRequestQuantiles = []float64{0.5, 0.9}
+
+func collectPullRequests(ch chan<- prometheus.Metric, host string, repoStat github.RepoStats, stats github.PullRequestStats, archived string) {
+	ch <- prometheus.MustNewConstMetric(metrics["closed_pulls"].desc, prometheus.GaugeValue, float64(stats.Merged), host, repoStat.Name, archived, "merged")
+	ch <- prometheus.MustNewConstMetric(metrics["closed_pulls"].desc, prometheus.GaugeValue, float64(stats.Unmerged), host, repoStat.Name, archived, "unmerged")
+
+	collectQuantiles(ch, metrics["pull_time_to_first_review"].desc, durations(stats.TimeToFirstReview), host, repoStat.Name, archived)
+	collectQuantiles(ch, metrics["pull_time_to_merge"].desc, durations(stats.TimeToMerge), host, repoStat.Name, archived)
+	lines := make([]float64, len(stats.Lines))
+	for i, l := range stats.Lines {
+		lines[i] = float64(l)
+	}
+	collectQuantiles(ch, metrics["pull_size"].desc, lines, host, repoStat.Name, archived)
+}
+
+// collectQuantiles reports the values as a summary, with the pullRequestQuantiles of the values. Nothing is reported if
+// there are no values.
+func collectQuantiles(ch chan<- prometheus.Metric, desc *prometheus.Desc, values []float64, labels ...string) {
+	if len(values) == 0 {
+		return
+	}
+	slices.Sort(values)
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	quantiles := make(map[float64]float64, len(pullRequestQuantiles))
+	for _, q := range pullRequestQuantiles {
+		quantiles[q] = quantile(values, q)
+	}
+	ch <- prometheus.MustNewConstSummary(desc, uint64(len(values)), sum, quantiles, labels...)
+}
+
+// quantile returns the q-quantile of the sorted values, using the nearest-rank method.
+func quantile(sorted []float64, q float64) float64 {
+	rank := int(q*float64(len(sorted))+0.5) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
+
+func durations(d []time.Duration) []float64 {
+	seconds := make([]float64, len(d))
+	for i := range d {
+		seconds[i] = d[i].Seconds()
+	}
+	return seconds
+}
@@ -0,0 +1,16 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_quantile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, 5.0, quantile(values, 0.5))
+	assert.Equal(t, 9.0, quantile(values, 0.9))
+	assert.Equal(t, 1.0, quantile(values, 0))
+	assert.Equal(t, 10.0, quantile(values, 1))
+	assert.Equal(t, 3.0, quantile([]float64{3}, 0.9))
+}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type QuotaClient interface {
+	GetQuotas(context.Context) ([]github.Quota, error)
+}
+
+// quotas records the API quota after each refresh, to predict when the quota runs out.
+type quotas map[string]quota
+
+type quota struct {
+	observed time.Time
+	github.Quota
+	// rate is the number of requests per second consumed between the last two refreshes.
+	rate float64
+}
+
+// observe records the quotas reported at time now. The consumption rate is the increase in used requests since the
+// previous refresh. If the quota was reset since the previous refresh, the previous rate is kept.
+func (q quotas) observe(reported []github.Quota, now time.Time) {
+	for _, r := range reported {
+		previous, ok := q[r.Resource]
+		current := quota{Quota: r, observed: now, rate: previous.rate}
+		if ok && r.Reset.Equal(previous.Reset) && now.After(previous.observed) {
+			current.rate = max(0, float64(r.Used-previous.Used)/now.Sub(previous.observed).Seconds())
+		}
+		q[r.Resource] = current
+	}
+}
+
+// exhaustion returns the time at which the quota runs out at the current consumption rate. It returns false if the
+// quota is reset before it runs out.
+func (q quota) exhaustion() (time.Time, bool) {
+	if q.rate <= 0 {
+		return time.Time{}, false
+	}
+	exhaustion := q.observed.Add(time.Duration(float64(q.Remaining) / q.rate * float64(time.Second)))
+	return exhaustion, exhaustion.Before(q.Reset)
+}
+
+func (q quotas) collect(ch chan<- prometheus.Metric, host string, source string) {
+	for resource, current := range q {
+		ch <- prometheus.MustNewConstMetric(metrics["rate_limit"].desc, prometheus.GaugeValue, float64(current.Limit), host, source, resource)
+		ch <- prometheus.MustNewConstMetric(metrics["rate_limit_remaining"].desc, prometheus.GaugeValue, float64(current.Remaining), host, source, resource)
+		ch <- prometheus.MustNewConstMetric(metrics["rate_limit_used"].desc, prometheus.GaugeValue, float64(current.Used), host, source, resource)
+		ch <- prometheus.MustNewConstMetric(metrics["rate_limit_reset"].desc, prometheus.GaugeValue, float64(current.Reset.Unix()), host, source, resource)
+		if exhaustion, ok := current.exhaustion(); ok {
+			ch <- prometheus.MustNewConstMetric(metrics["rate_limit_exhaustion"].desc, prometheus.GaugeValue, float64(exhaustion.Unix()), host, source, resource)
+		}
+	}
+}
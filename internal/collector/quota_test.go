@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_quotas(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(time.Hour)
+	q := make(quotas)
+
+	// a single observation doesn't have a consumption rate
+	q.observe([]github.Quota{{Resource: "core", Limit: 5000, Remaining: 4000, Used: 1000, Reset: reset}}, now)
+	_, ok := q["core"].exhaustion()
+	assert.False(t, ok)
+
+	// 1000 requests in 10 minutes: the remaining 3000 requests run out in 30 minutes, before the reset
+	now = now.Add(10 * time.Minute)
+	q.observe([]github.Quota{{Resource: "core", Limit: 5000, Remaining: 3000, Used: 2000, Reset: reset}}, now)
+	exhaustion, ok := q["core"].exhaustion()
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(30*time.Minute), exhaustion)
+
+	// 100 requests in 10 minutes: the quota is reset before it runs out
+	now = now.Add(10 * time.Minute)
+	q.observe([]github.Quota{{Resource: "core", Limit: 5000, Remaining: 2900, Used: 2100, Reset: reset}}, now)
+	_, ok = q["core"].exhaustion()
+	assert.False(t, ok)
+
+	// the quota was reset: keep the previous rate
+	now = now.Add(time.Hour)
+	q.observe([]github.Quota{{Resource: "core", Limit: 5000, Remaining: 5000, Reset: now.Add(time.Hour)}}, now)
+	assert.InDelta(t, 100.0/600, q["core"].rate, 0.0001)
+}
@@ -0,0 +1,462 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/label"
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/spf13/viper"
+)
+
+// Configuration lists all supported configuration options. It is used to strictly decode the configuration,
+// i.e. any option not listed here is rejected.
+type Configuration struct {
+	Addr    string   `mapstructure:"addr"`
+	Push    Push     `mapstructure:"push"`
+	OTLP    OTLP     `mapstructure:"otlp"`
+	Tracing Tracing  `mapstructure:"tracing"`
+	Webhook Webhook  `mapstructure:"webhook"`
+	Git     Git      `mapstructure:"git"`
+	Repos   Repos    `mapstructure:"repos"`
+	Sources []Source `mapstructure:"sources"`
+	Metrics Metrics  `mapstructure:"metrics"`
+	Debug   bool     `mapstructure:"debug"`
+}
+
+type Repos struct {
+	User            []string         `mapstructure:"user"`
+	Repo            []string         `mapstructure:"repo"`
+	Topics          []Topic          `mapstructure:"topics"`
+	Teams           []Team           `mapstructure:"teams"`
+	TeamTopicPrefix string           `mapstructure:"team_topic_prefix"`
+	TeamCache       time.Duration    `mapstructure:"team_cache"`
+	Properties      []string         `mapstructure:"properties"`
+	PropertyFilters []PropertyFilter `mapstructure:"property_filters"`
+	Archived        bool             `mapstructure:"archived"`
+	Activity        bool             `mapstructure:"activity"`
+	Compliance      bool             `mapstructure:"compliance"`
+	PullRequests    PullRequests     `mapstructure:"pull_requests"`
+}
+
+// Topic selects the unarchived repos of an org that have a topic.
+type Topic struct {
+	Org   string `mapstructure:"org"`
+	Topic string `mapstructure:"topic"`
+}
+
+// Team selects the repos that a team of an org has access to. Team is the team's slug.
+type Team struct {
+	Org  string `mapstructure:"org"`
+	Team string `mapstructure:"team"`
+}
+
+// PropertyFilter selects the repos that have one of the values for a custom property.
+type PropertyFilter struct {
+	Property string   `mapstructure:"property"`
+	Values   []string `mapstructure:"values"`
+}
+
+type PullRequests struct {
+	Window  time.Duration `mapstructure:"window"`
+	Enabled bool          `mapstructure:"enabled"`
+}
+
+// Metrics selects the reported metric families, by metric name. See collector.Families. Unknown family names are
+// reported by the validate command.
+type Metrics struct {
+	MaxSeries map[string]int `mapstructure:"max_series"`
+	Enabled   []string       `mapstructure:"enabled"`
+	Disabled  []string       `mapstructure:"disabled"`
+}
+
+type Git struct {
+	Token                 string        `mapstructure:"token"`
+	TokenFile             string        `mapstructure:"token_file"`
+	TokenEnv              string        `mapstructure:"token_env"`
+	TokenCommand          []string      `mapstructure:"token_command"`
+	Tokens                []string      `mapstructure:"tokens"`
+	Retry                 Retry         `mapstructure:"retry"`
+	Adaptive              Adaptive      `mapstructure:"adaptive"`
+	TokenTTL              time.Duration `mapstructure:"token_ttl"`
+	Cache                 time.Duration `mapstructure:"cache"`
+	URL                   string        `mapstructure:"url"`
+	Timeout               time.Duration `mapstructure:"timeout"`
+	MaxConcurrentRequests int           `mapstructure:"max_concurrent_requests"`
+	MaxConcurrentRepos    int           `mapstructure:"max_concurrent_repos"`
+}
+
+// Adaptive configures the adaptive refresh interval. git.cache is the longest refresh interval.
+type Adaptive struct {
+	Budget      float64       `mapstructure:"budget"`
+	MinInterval time.Duration `mapstructure:"min_interval"`
+	Enabled     bool          `mapstructure:"enabled"`
+}
+
+// Source is an additional GitHub host or account, with its own credentials and repos. If zero, TokenTTL and
+// MaxConcurrentRequests default to the values in the git section. Name identifies the source: see ID.
+type Source struct {
+	Name                  string        `mapstructure:"name"`
+	URL                   string        `mapstructure:"url"`
+	Token                 string        `mapstructure:"token"`
+	TokenFile             string        `mapstructure:"token_file"`
+	TokenEnv              string        `mapstructure:"token_env"`
+	TokenCommand          []string      `mapstructure:"token_command"`
+	Tokens                []string      `mapstructure:"tokens"`
+	Repos                 SourceRepos   `mapstructure:"repos"`
+	TokenTTL              time.Duration `mapstructure:"token_ttl"`
+	MaxConcurrentRequests int           `mapstructure:"max_concurrent_requests"`
+}
+
+type SourceRepos struct {
+	User   []string `mapstructure:"user"`
+	Repo   []string `mapstructure:"repo"`
+	Topics []Topic  `mapstructure:"topics"`
+	Teams  []Team   `mapstructure:"teams"`
+}
+
+// Host returns the name of the source's GitHub host, as reported in the host label.
+func (s Source) Host() string {
+	return github.HostName(s.URL)
+}
+
+// ID returns the source's name, or its host if it has no name. The ID is reported in the source label of the rate limit
+// and token metrics, and names the source's record directory, so sources on the same host need different names.
+func (s Source) ID() string {
+	return cmp.Or(s.Name, s.Host())
+}
+
+// AllSources returns the sources to collect. The repos and credentials in the repos and git sections form the first
+// source, unless additional sources are configured and the repos section is empty. Zero TokenTTL and
+// MaxConcurrentRequests are set to their values in the git section.
+func (c Configuration) AllSources() []Source {
+	var sources []Source
+	if len(c.Repos.User) > 0 || len(c.Repos.Repo) > 0 || len(c.Repos.Topics) > 0 || len(c.Repos.Teams) > 0 || len(c.Sources) == 0 {
+		sources = append(sources, Source{
+			URL:                   c.Git.URL,
+			Token:                 c.Git.Token,
+			TokenFile:             c.Git.TokenFile,
+			TokenEnv:              c.Git.TokenEnv,
+			TokenCommand:          c.Git.TokenCommand,
+			Tokens:                c.Git.Tokens,
+			Repos:                 SourceRepos{User: c.Repos.User, Repo: c.Repos.Repo, Topics: c.Repos.Topics, Teams: c.Repos.Teams},
+			TokenTTL:              c.Git.TokenTTL,
+			MaxConcurrentRequests: c.Git.MaxConcurrentRequests,
+		})
+	}
+	for _, source := range c.Sources {
+		if source.TokenTTL == 0 {
+			source.TokenTTL = c.Git.TokenTTL
+		}
+		if source.MaxConcurrentRequests == 0 {
+			source.MaxConcurrentRequests = c.Git.MaxConcurrentRequests
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+type Retry struct {
+	Attempts   int           `mapstructure:"attempts"`
+	Backoff    time.Duration `mapstructure:"backoff"`
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+}
+
+type Push struct {
+	Grouping map[string]string `mapstructure:"grouping"`
+	Target   string            `mapstructure:"target"`
+	URL      string            `mapstructure:"url"`
+	Job      string            `mapstructure:"job"`
+	Username string            `mapstructure:"username"`
+	Password string            `mapstructure:"password"`
+	Interval time.Duration     `mapstructure:"interval"`
+	Backoff  time.Duration     `mapstructure:"backoff"`
+	Retries  int               `mapstructure:"retries"`
+}
+
+type OTLP struct {
+	Headers  map[string]string `mapstructure:"headers"`
+	Resource map[string]string `mapstructure:"resource"`
+	Protocol string            `mapstructure:"protocol"`
+	Endpoint string            `mapstructure:"endpoint"`
+	Interval time.Duration     `mapstructure:"interval"`
+	Insecure bool              `mapstructure:"insecure"`
+}
+
+type Webhook struct {
+	Secret string `mapstructure:"secret"`
+}
+
+type Tracing struct {
+	Headers  map[string]string `mapstructure:"headers"`
+	Exporter string            `mapstructure:"exporter"`
+	Protocol string            `mapstructure:"protocol"`
+	Endpoint string            `mapstructure:"endpoint"`
+	Insecure bool              `mapstructure:"insecure"`
+}
+
+// Load decodes the configuration held by v. Unknown options are reported as an error.
+func Load(v *viper.Viper) (Configuration, error) {
+	var cfg Configuration
+	if err := v.UnmarshalExact(&cfg); err != nil {
+		return cfg, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+var (
+	userNameRegExp = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?$`)
+	repoNameRegExp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	// topicRegExp matches GitHub topics: lowercase letters, digits and hyphens, starting with a letter or digit.
+	topicRegExp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+	// teamSlugRegExp matches the slugs of GitHub teams: the team name in lowercase, with special characters replaced by hyphens.
+	teamSlugRegExp = regexp.MustCompile(`^[a-z0-9_-]+$`)
+	// sourceNameRegExp matches the source names that can be used as a directory name, like host names.
+	sourceNameRegExp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+)
+
+// Validate checks the configuration for invalid values. All problems found are returned.
+func (c Configuration) Validate() error {
+	var errs []error
+	if c.Addr != "" || (c.Push.Target == "" && c.OTLP.Protocol == "") {
+		if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("addr: invalid address %q: %w", c.Addr, err))
+		}
+	}
+	errs = append(errs, c.Repos.validateProperties()...)
+	if c.Repos.PullRequests.Enabled && c.Repos.PullRequests.Window <= 0 {
+		errs = append(errs, fmt.Errorf("repos.pull_requests.window: must be positive, got %s", c.Repos.PullRequests.Window))
+	}
+	ids := make(map[string]bool)
+	var teams bool
+	validateSource := func(source Source, repos string, git string) {
+		errs = append(errs, source.validate(repos, git)...)
+		teams = teams || len(source.Repos.Teams) > 0
+		if ids[source.ID()] {
+			errs = append(errs, fmt.Errorf("%s: duplicate source %q: set name to tell sources on the same host apart", git, source.ID()))
+		}
+		ids[source.ID()] = true
+	}
+	if sources := c.AllSources(); len(sources) > len(c.Sources) {
+		validateSource(sources[0], "repos", "git")
+	}
+	for i, source := range c.Sources {
+		git := fmt.Sprintf("sources[%d]", i)
+		validateSource(source, git+".repos", git)
+		if source.Name != "" && !sourceNameRegExp.MatchString(source.Name) {
+			errs = append(errs, fmt.Errorf("%s.name: invalid source name %q", git, source.Name))
+		}
+		if source.TokenTTL < 0 {
+			errs = append(errs, fmt.Errorf("%s.token_ttl: must not be negative, got %s", git, source.TokenTTL))
+		}
+		if source.MaxConcurrentRequests < 0 {
+			errs = append(errs, fmt.Errorf("%s.max_concurrent_requests: must not be negative, got %d", git, source.MaxConcurrentRequests))
+		}
+	}
+	if teams && c.Repos.TeamCache <= 0 {
+		errs = append(errs, fmt.Errorf("repos.team_cache: must be positive, got %s", c.Repos.TeamCache))
+	}
+	if c.Git.Cache <= 0 {
+		errs = append(errs, fmt.Errorf("git.cache: must be positive, got %s", c.Git.Cache))
+	}
+	if c.Git.TokenTTL < 0 {
+		errs = append(errs, fmt.Errorf("git.token_ttl: must not be negative, got %s", c.Git.TokenTTL))
+	}
+	if c.Git.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("git.timeout: must be positive, got %s", c.Git.Timeout))
+	}
+	if c.Git.MaxConcurrentRequests < 1 {
+		errs = append(errs, fmt.Errorf("git.max_concurrent_requests: must be at least 1, got %d", c.Git.MaxConcurrentRequests))
+	}
+	if c.Git.MaxConcurrentRepos < 1 {
+		errs = append(errs, fmt.Errorf("git.max_concurrent_repos: must be at least 1, got %d", c.Git.MaxConcurrentRepos))
+	}
+	if c.Git.Retry.Attempts < 1 {
+		errs = append(errs, fmt.Errorf("git.retry.attempts: must be at least 1, got %d", c.Git.Retry.Attempts))
+	}
+	if c.Git.Retry.Backoff < 0 || c.Git.Retry.MaxBackoff < c.Git.Retry.Backoff {
+		errs = append(errs, fmt.Errorf("git.retry: backoff (%s) must be between 0 and max_backoff (%s)", c.Git.Retry.Backoff, c.Git.Retry.MaxBackoff))
+	}
+	if c.Git.Adaptive.Enabled {
+		errs = append(errs, c.Git.Adaptive.validate(c.Git.Cache)...)
+	}
+	errs = append(errs, c.Metrics.validate()...)
+	if c.Webhook.Secret != "" && c.Addr == "" {
+		errs = append(errs, errors.New("webhook: requires addr to be set"))
+	}
+	if c.Push.Target != "" {
+		errs = append(errs, c.Push.validate()...)
+	}
+	if c.OTLP.Protocol != "" {
+		errs = append(errs, c.OTLP.validate()...)
+	}
+	if c.Tracing.Exporter != "" {
+		errs = append(errs, c.Tracing.validate()...)
+	}
+	return errors.Join(errs...)
+}
+
+func (s Source) validate(repos string, git string) []error {
+	var errs []error
+	for _, user := range s.Repos.User {
+		if !userNameRegExp.MatchString(user) {
+			errs = append(errs, fmt.Errorf("%s.user: invalid user name %q", repos, user))
+		}
+	}
+	for _, repo := range s.Repos.Repo {
+		if err := validateRepoName(repo); err != nil {
+			errs = append(errs, fmt.Errorf("%s.repo: %w", repos, err))
+		}
+	}
+	for _, topic := range s.Repos.Topics {
+		if !userNameRegExp.MatchString(topic.Org) {
+			errs = append(errs, fmt.Errorf("%s.topics: invalid org name %q", repos, topic.Org))
+		}
+		if !topicRegExp.MatchString(topic.Topic) {
+			errs = append(errs, fmt.Errorf("%s.topics: invalid topic %q", repos, topic.Topic))
+		}
+	}
+	for _, team := range s.Repos.Teams {
+		if !userNameRegExp.MatchString(team.Org) {
+			errs = append(errs, fmt.Errorf("%s.teams: invalid org name %q", repos, team.Org))
+		}
+		if !teamSlugRegExp.MatchString(team.Team) {
+			errs = append(errs, fmt.Errorf("%s.teams: invalid team slug %q", repos, team.Team))
+		}
+	}
+	if len(s.Repos.User) == 0 && len(s.Repos.Repo) == 0 && len(s.Repos.Topics) == 0 && len(s.Repos.Teams) == 0 {
+		errs = append(errs, fmt.Errorf("%s: no users, repos, topics or teams configured", repos))
+	}
+	if s.Token == "" && s.TokenFile == "" && s.TokenEnv == "" && len(s.TokenCommand) == 0 && len(s.Tokens) == 0 {
+		errs = append(errs, fmt.Errorf("%s: no token configured", git))
+	}
+	for i, token := range s.Tokens {
+		if strings.TrimSpace(token) == "" {
+			errs = append(errs, fmt.Errorf("%s.tokens: token %d is empty", git, i+1))
+		}
+	}
+	if s.URL != "" {
+		if u, err := url.Parse(s.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s.url: invalid url %q", git, s.URL))
+		}
+	}
+	return errs
+}
+
+func (r Repos) validateProperties() []error {
+	var errs []error
+	// the labels of the properties must not clash with each other, or with the other labels of the metric
+	labels := map[string]string{"host": "", "repo": "", "archived": ""}
+	for _, property := range r.Properties {
+		if property == "" {
+			errs = append(errs, errors.New("repos.properties: property name must not be empty"))
+			continue
+		}
+		name := label.Property(property)
+		if other, ok := labels[name]; ok {
+			errs = append(errs, fmt.Errorf("repos.properties: label %q of property %q is already used by %q", name, property, cmp.Or(other, name)))
+			continue
+		}
+		labels[name] = property
+	}
+	for i, filter := range r.PropertyFilters {
+		if filter.Property == "" {
+			errs = append(errs, fmt.Errorf("repos.property_filters[%d]: property name must not be empty", i))
+		}
+		if len(filter.Values) == 0 {
+			errs = append(errs, fmt.Errorf("repos.property_filters[%d]: no values configured", i))
+		}
+	}
+	return errs
+}
+
+func (m Metrics) validate() []error {
+	var errs []error
+	for _, family := range slices.Sorted(maps.Keys(m.MaxSeries)) {
+		if maxSeries := m.MaxSeries[family]; maxSeries <= 0 {
+			errs = append(errs, fmt.Errorf("metrics.max_series.%s: must be positive, got %d", family, maxSeries))
+		}
+	}
+	return errs
+}
+
+func (a Adaptive) validate(maxInterval time.Duration) []error {
+	var errs []error
+	if a.Budget <= 0 || a.Budget > 1 {
+		errs = append(errs, fmt.Errorf("git.adaptive.budget: must be between 0 and 1, got %g", a.Budget))
+	}
+	if a.MinInterval <= 0 || a.MinInterval > maxInterval {
+		errs = append(errs, fmt.Errorf("git.adaptive.min_interval: must be between 0 and git.cache (%s), got %s", maxInterval, a.MinInterval))
+	}
+	return errs
+}
+
+func (p Push) validate() []error {
+	var errs []error
+	if p.Target != "pushgateway" && p.Target != "remote_write" {
+		errs = append(errs, fmt.Errorf("push.target: invalid target %q: expected pushgateway or remote_write", p.Target))
+	}
+	if u, err := url.Parse(p.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("push.url: invalid url %q", p.URL))
+	}
+	if p.Job == "" {
+		errs = append(errs, errors.New("push.job: must not be empty"))
+	}
+	if p.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("push.interval: must be positive, got %s", p.Interval))
+	}
+	if p.Retries < 0 {
+		errs = append(errs, fmt.Errorf("push.retries: must not be negative, got %d", p.Retries))
+	}
+	if p.Backoff < 0 {
+		errs = append(errs, fmt.Errorf("push.backoff: must not be negative, got %s", p.Backoff))
+	}
+	return errs
+}
+
+func (o OTLP) validate() []error {
+	var errs []error
+	if o.Protocol != "grpc" && o.Protocol != "http" {
+		errs = append(errs, fmt.Errorf("otlp.protocol: invalid protocol %q: expected grpc or http", o.Protocol))
+	}
+	if o.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("otlp.interval: must be positive, got %s", o.Interval))
+	}
+	return errs
+}
+
+func (t Tracing) validate() []error {
+	switch t.Exporter {
+	case "stdout":
+		return nil
+	case "otlp":
+		if t.Protocol != "grpc" && t.Protocol != "http" {
+			return []error{fmt.Errorf("tracing.protocol: invalid protocol %q: expected grpc or http", t.Protocol)}
+		}
+		return nil
+	default:
+		return []error{fmt.Errorf("tracing.exporter: invalid exporter %q: expected otlp or stdout", t.Exporter)}
+	}
+}
+
+func validateRepoName(repo string) error {
+	user, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return fmt.Errorf("invalid repo name %q: expected <owner>/<repo>", repo)
+	}
+	if !userNameRegExp.MatchString(user) {
+		return fmt.Errorf("invalid repo name %q: invalid owner %q", repo, user)
+	}
+	if !repoNameRegExp.MatchString(name) {
+		return fmt.Errorf("invalid repo name %q: invalid repo %q", repo, name)
+	}
+	return nil
+}
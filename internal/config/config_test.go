@@ -0,0 +1,239 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr assert.ErrorAssertionFunc
+		want    Configuration
+	}{
+		{
+			name: "valid",
+			content: `
+addr: :9090
+repos:
+  user: [ clambin ]
+  repo: [ clambin/github-exporter ]
+git:
+  token: foo
+  cache: 1h
+`,
+			wantErr: assert.NoError,
+			want: Configuration{
+				Addr:  ":9090",
+				Repos: Repos{User: []string{"clambin"}, Repo: []string{"clambin/github-exporter"}},
+				Git:   Git{Token: "foo", Cache: time.Hour},
+			},
+		},
+		{
+			name: "unknown key",
+			content: `
+repos:
+  users: [ clambin ]
+`,
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.SetConfigType("yaml")
+			require.NoError(t, v.ReadConfig(bytes.NewBufferString(tt.content)))
+			cfg, err := Load(v)
+			tt.wantErr(t, err)
+			if err == nil {
+				assert.Equal(t, tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestConfiguration_AllSources(t *testing.T) {
+	cfg := Configuration{
+		Repos: Repos{User: []string{"clambin"}},
+		Git:   Git{Token: "foo", TokenTTL: time.Minute, MaxConcurrentRequests: 25},
+		Sources: []Source{
+			{URL: "https://github.example.com/api/v3/", Token: "bar", Repos: SourceRepos{User: []string{"foo"}}},
+		},
+	}
+	sources := cfg.AllSources()
+	require.Len(t, sources, 2)
+	assert.Equal(t, Source{Token: "foo", TokenTTL: time.Minute, MaxConcurrentRequests: 25, Repos: SourceRepos{User: []string{"clambin"}}}, sources[0])
+	assert.Equal(t, "github.com", sources[0].Host())
+	assert.Equal(t, "github.example.com", sources[1].Host())
+	assert.Equal(t, "github.com", sources[0].ID())
+	// defaults are taken from the git section
+	assert.Equal(t, time.Minute, sources[1].TokenTTL)
+	assert.Equal(t, 25, sources[1].MaxConcurrentRequests)
+
+	// without repos in the repos section, only the additional sources are collected
+	cfg.Repos = Repos{}
+	assert.Equal(t, sources[1:], cfg.AllSources())
+}
+
+func TestConfiguration_Validate(t *testing.T) {
+	valid := Configuration{
+		Addr:  ":9090",
+		Repos: Repos{User: []string{"clambin"}, Repo: []string{"clambin/github-exporter"}},
+		Git:   Git{Token: "foo", Cache: time.Hour, Timeout: 10 * time.Second, MaxConcurrentRequests: 25, MaxConcurrentRepos: 10, Retry: Retry{Attempts: 3, Backoff: time.Second, MaxBackoff: 10 * time.Second}},
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Configuration)
+		wantErr string
+	}{
+		{name: "valid", modify: func(*Configuration) {}},
+		{name: "bad address", modify: func(c *Configuration) { c.Addr = "9090" }, wantErr: `addr: invalid address "9090"`},
+		{name: "missing slash", modify: func(c *Configuration) { c.Repos.Repo = []string{"clambin"} }, wantErr: `repos.repo: invalid repo name "clambin": expected <owner>/<repo>`},
+		{name: "too many slashes", modify: func(c *Configuration) { c.Repos.Repo = []string{"foo/bar/snafu"} }, wantErr: `repos.repo: invalid repo name "foo/bar/snafu": invalid repo "bar/snafu"`},
+		{name: "bad user", modify: func(c *Configuration) { c.Repos.User = []string{"foo/bar"} }, wantErr: `repos.user: invalid user name "foo/bar"`},
+		{name: "topics only", modify: func(c *Configuration) {
+			c.Repos = Repos{Topics: []Topic{{Org: "clambin", Topic: "team-payments"}}}
+		}},
+		{name: "bad topic", modify: func(c *Configuration) {
+			c.Repos.Topics = []Topic{{Org: "clambin", Topic: "Team Payments"}}
+		}, wantErr: `repos.topics: invalid topic "Team Payments"`},
+		{name: "teams only", modify: func(c *Configuration) {
+			c.Repos = Repos{Teams: []Team{{Org: "clambin", Team: "payments"}}, TeamCache: 6 * time.Hour}
+		}},
+		{name: "bad team", modify: func(c *Configuration) {
+			c.Repos.Teams = []Team{{Org: "clambin", Team: "Payments Team"}}
+			c.Repos.TeamCache = 6 * time.Hour
+		}, wantErr: `repos.teams: invalid team slug "Payments Team"`},
+		{name: "zero team cache", modify: func(c *Configuration) {
+			c.Repos.Teams = []Team{{Org: "clambin", Team: "payments"}}
+		}, wantErr: "repos.team_cache: must be positive, got 0s"},
+		{name: "properties", modify: func(c *Configuration) {
+			c.Repos.Properties = []string{"service-tier", "cost-center"}
+			c.Repos.PropertyFilters = []PropertyFilter{{Property: "service-tier", Values: []string{"1", "2"}}}
+		}},
+		{name: "duplicate property label", modify: func(c *Configuration) {
+			c.Repos.Properties = []string{"service-tier", "service_tier"}
+		}, wantErr: `repos.properties: label "service_tier" of property "service_tier" is already used by "service-tier"`},
+		{name: "reserved property label", modify: func(c *Configuration) {
+			c.Repos.Properties = []string{"repo"}
+		}, wantErr: `repos.properties: label "repo" of property "repo" is already used by "repo"`},
+		{name: "property filter without values", modify: func(c *Configuration) {
+			c.Repos.PropertyFilters = []PropertyFilter{{Property: "service-tier"}}
+		}, wantErr: "repos.property_filters[0]: no values configured"},
+		{name: "bad topic org", modify: func(c *Configuration) {
+			c.Repos.Topics = []Topic{{Org: "", Topic: "team-payments"}}
+		}, wantErr: `repos.topics: invalid org name ""`},
+		{name: "no repos", modify: func(c *Configuration) { c.Repos = Repos{} }, wantErr: "repos: no users, repos, topics or teams configured"},
+		{name: "no token", modify: func(c *Configuration) { c.Git.Token = "" }, wantErr: "git: no token configured"},
+		{name: "empty pool token", modify: func(c *Configuration) { c.Git.Tokens = []string{"foo", ""} }, wantErr: "git.tokens: token 2 is empty"},
+		{name: "zero cache", modify: func(c *Configuration) { c.Git.Cache = 0 }, wantErr: "git.cache: must be positive, got 0s"},
+		{name: "push without listener", modify: func(c *Configuration) {
+			c.Addr = ""
+			c.Push = Push{Target: "pushgateway", URL: "http://localhost:9091", Job: "github-exporter", Interval: time.Minute}
+		}},
+		{name: "webhook without listener", modify: func(c *Configuration) {
+			c.Addr = ""
+			c.OTLP = OTLP{Protocol: "grpc", Interval: time.Minute}
+			c.Webhook.Secret = "secret"
+		}, wantErr: "webhook: requires addr to be set"},
+		{name: "no listener", modify: func(c *Configuration) { c.Addr = "" }, wantErr: `addr: invalid address ""`},
+		{name: "bad push target", modify: func(c *Configuration) {
+			c.Push = Push{Target: "foo", URL: "http://localhost:9091", Job: "github-exporter", Interval: time.Minute}
+		}, wantErr: `push.target: invalid target "foo"`},
+		{name: "bad push url", modify: func(c *Configuration) {
+			c.Push = Push{Target: "remote_write", URL: "localhost", Job: "github-exporter", Interval: time.Minute}
+		}, wantErr: `push.url: invalid url "localhost"`},
+		{name: "otlp without listener", modify: func(c *Configuration) {
+			c.Addr = ""
+			c.OTLP = OTLP{Protocol: "grpc", Interval: time.Minute}
+		}},
+		{name: "bad otlp protocol", modify: func(c *Configuration) { c.OTLP = OTLP{Protocol: "udp", Interval: time.Minute} }, wantErr: `otlp.protocol: invalid protocol "udp"`},
+		{name: "stdout tracing", modify: func(c *Configuration) { c.Tracing = Tracing{Exporter: "stdout"} }},
+		{name: "bad tracing exporter", modify: func(c *Configuration) { c.Tracing = Tracing{Exporter: "jaeger"} }, wantErr: `tracing.exporter: invalid exporter "jaeger"`},
+		{name: "bad tracing protocol", modify: func(c *Configuration) { c.Tracing = Tracing{Exporter: "otlp"} }, wantErr: `tracing.protocol: invalid protocol ""`},
+		{name: "no attempts", modify: func(c *Configuration) { c.Git.Retry.Attempts = 0 }, wantErr: "git.retry.attempts: must be at least 1, got 0"},
+		{name: "bad backoff", modify: func(c *Configuration) { c.Git.Retry.MaxBackoff = 0 }, wantErr: "git.retry: backoff (1s) must be between 0 and max_backoff (0s)"},
+		{name: "no pull request window", modify: func(c *Configuration) { c.Repos.PullRequests.Enabled = true }, wantErr: "repos.pull_requests.window: must be positive, got 0s"},
+		{name: "github enterprise", modify: func(c *Configuration) { c.Git.URL = "https://github.example.com/api/v3/" }},
+		{name: "bad git url", modify: func(c *Configuration) { c.Git.URL = "github.example.com" }, wantErr: `git.url: invalid url "github.example.com"`},
+		{name: "no timeout", modify: func(c *Configuration) { c.Git.Timeout = 0 }, wantErr: "git.timeout: must be positive, got 0s"},
+		{name: "no concurrent requests", modify: func(c *Configuration) { c.Git.MaxConcurrentRequests = 0 }, wantErr: "git.max_concurrent_requests: must be at least 1, got 0"},
+		{name: "no concurrent repos", modify: func(c *Configuration) { c.Git.MaxConcurrentRepos = 0 }, wantErr: "git.max_concurrent_repos: must be at least 1, got 0"},
+		{name: "adaptive refresh", modify: func(c *Configuration) {
+			c.Git.Adaptive = Adaptive{Enabled: true, Budget: 0.5, MinInterval: time.Minute}
+		}},
+		{name: "bad adaptive budget", modify: func(c *Configuration) {
+			c.Git.Adaptive = Adaptive{Enabled: true, Budget: 1.5, MinInterval: time.Minute}
+		}, wantErr: "git.adaptive.budget: must be between 0 and 1, got 1.5"},
+		{name: "bad adaptive min interval", modify: func(c *Configuration) {
+			c.Git.Adaptive = Adaptive{Enabled: true, Budget: 0.5, MinInterval: 2 * time.Hour}
+		}, wantErr: "git.adaptive.min_interval: must be between 0 and git.cache (1h0m0s), got 2h0m0s"},
+		{name: "metric families", modify: func(c *Configuration) {
+			c.Metrics = Metrics{
+				Enabled:   []string{"github_exporter_stars", "github_exporter_repo_properties"},
+				Disabled:  []string{"github_exporter_weekly_commits"},
+				MaxSeries: map[string]int{"github_exporter_repo_team": 1000},
+			}
+		}},
+		{name: "bad max series", modify: func(c *Configuration) {
+			c.Metrics.MaxSeries = map[string]int{"github_exporter_repo_team": 0}
+		}, wantErr: "metrics.max_series.github_exporter_repo_team: must be positive, got 0"},
+		{name: "additional source", modify: func(c *Configuration) {
+			c.Sources = []Source{{URL: "https://github.example.com/api/v3/", TokenEnv: "GHES_TOKEN", Repos: SourceRepos{User: []string{"foo"}}}}
+		}},
+		{name: "sources only", modify: func(c *Configuration) {
+			c.Repos = Repos{}
+			c.Git.Token = ""
+			c.Sources = []Source{{URL: "https://github.example.com/api/v3/", TokenEnv: "GHES_TOKEN", Repos: SourceRepos{User: []string{"foo"}}}}
+		}},
+		{name: "source without token", modify: func(c *Configuration) {
+			c.Sources = []Source{{URL: "https://github.example.com/api/v3/", Repos: SourceRepos{User: []string{"foo"}}}}
+		}, wantErr: "sources[0]: no token configured"},
+		{name: "source without repos", modify: func(c *Configuration) {
+			c.Sources = []Source{{URL: "https://github.example.com/api/v3/", Token: "foo"}}
+		}, wantErr: "sources[0].repos: no users, repos, topics or teams configured"},
+		{name: "bad source repo", modify: func(c *Configuration) {
+			c.Sources = []Source{{URL: "https://github.example.com/api/v3/", Token: "foo", Repos: SourceRepos{Repo: []string{"foo"}}}}
+		}, wantErr: `sources[0].repos.repo: invalid repo name "foo"`},
+		{name: "duplicate source", modify: func(c *Configuration) {
+			c.Sources = []Source{{Token: "foo", Repos: SourceRepos{User: []string{"foo"}}}}
+		}, wantErr: `sources[0]: duplicate source "github.com"`},
+		{name: "named source on the same host", modify: func(c *Configuration) {
+			c.Sources = []Source{{Name: "private", Token: "foo", Repos: SourceRepos{User: []string{"foo"}}}}
+		}},
+		{name: "duplicate source name", modify: func(c *Configuration) {
+			c.Sources = []Source{
+				{Name: "private", Token: "foo", Repos: SourceRepos{User: []string{"foo"}}},
+				{Name: "private", URL: "https://github.example.com/api/v3/", Token: "foo", Repos: SourceRepos{User: []string{"foo"}}},
+			}
+		}, wantErr: `sources[1]: duplicate source "private"`},
+		{name: "bad source name", modify: func(c *Configuration) {
+			c.Sources = []Source{{Name: "../private", Token: "foo", Repos: SourceRepos{User: []string{"foo"}}}}
+		}, wantErr: `sources[0].name: invalid source name "../private"`},
+		{name: "negative source ttl", modify: func(c *Configuration) {
+			c.Sources = []Source{{URL: "https://github.example.com/api/v3/", Token: "foo", TokenTTL: -time.Second, Repos: SourceRepos{User: []string{"foo"}}}}
+		}, wantErr: "sources[0].token_ttl: must not be negative, got -1s"},
+		{name: "negative ttl", modify: func(c *Configuration) { c.Git.TokenTTL = -time.Second }, wantErr: "git.token_ttl: must not be negative, got -1s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
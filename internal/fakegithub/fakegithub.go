@@ -0,0 +1,436 @@
+// Package fakegithub provides an in-process fake of the GitHub API, to test the exporter end to end.
+//
+// The server serves repos, pull requests, team repos and custom properties, searches repos by topic, reports all
+// branches as unprotected, paginates results with Link headers, reports the rate limit of each token in the X-RateLimit
+// headers and on /rate_limit, and can be told to fail requests.
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+)
+
+// Repo is a repo served by the Server.
+type Repo struct {
+	Owner        string
+	Name         string
+	PullRequests []PullRequest
+	Stars        int
+	Forks        int
+	// OpenIssues is the number of open issues, excluding pull requests.
+	OpenIssues int
+	Topics     []string
+	// Teams holds the role of each team of the owner that has access to the repo, by team slug.
+	Teams map[string]string
+	// Properties holds the values of the repo's custom properties, by property name.
+	Properties map[string]string
+	Archived   bool
+}
+
+// PullRequest is a pull request of a Repo.
+type PullRequest struct {
+	Number int
+	Open   bool
+}
+
+// Fault makes the Server fail requests.
+type Fault struct {
+	// Path is the path of the requests to fail. If empty, all requests fail.
+	Path string
+	// StatusCode is the status code of the response.
+	StatusCode int
+	// Count is the number of requests to fail. If zero, all requests fail.
+	Count int
+	// CloseConnection closes the connection instead of sending a response.
+	CloseConnection bool
+}
+
+// Server is a fake GitHub API server. Use New to create a Server and Close to shut it down.
+type Server struct {
+	*httptest.Server
+	repos    map[string]Repo
+	faults   []*Fault
+	requests map[string]int
+	// tokenRateLimits holds the rate limit of the tokens that don't have the default rateLimit
+	tokenRateLimits map[string]int
+	// used holds the number of requests in the rate limit window, by token
+	used      map[string]int
+	rateLimit int
+	reset     time.Time
+	lock      sync.Mutex
+}
+
+const defaultRateLimit = 5000
+
+// New starts a Server with the given repos.
+func New(repos ...Repo) *Server {
+	s := Server{
+		repos:           make(map[string]Repo),
+		requests:        make(map[string]int),
+		tokenRateLimits: make(map[string]int),
+		used:            make(map[string]int),
+		rateLimit:       defaultRateLimit,
+		reset:           time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	for _, repo := range repos {
+		s.AddRepo(repo)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{user}/repos", s.listUserRepos)
+	mux.HandleFunc("GET /repos/{owner}/{repo}", s.getRepo)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls", s.listPullRequests)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/stats/{stat}", s.getStats)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/branches/{branch}/protection", s.getBranchProtection)
+	mux.HandleFunc("GET /orgs/{org}/teams/{team}/repos", s.listTeamRepos)
+	mux.HandleFunc("GET /orgs/{org}/properties/values", s.listCustomPropertyValues)
+	mux.HandleFunc("GET /search/repositories", s.searchRepos)
+	mux.HandleFunc("GET /rate_limit", s.getRateLimit)
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		writeError(w, http.StatusNotFound, "Not Found")
+	})
+	s.Server = httptest.NewServer(s.middleware(mux))
+	return &s
+}
+
+// BaseURL returns the base URL of the server's API, for use with github.New.
+func (s *Server) BaseURL() string {
+	return s.URL + "/"
+}
+
+// AddRepo adds a repo, or replaces it if it already exists.
+func (s *Server) AddRepo(repo Repo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.repos[repo.Owner+"/"+repo.Name] = repo
+}
+
+// SetRateLimit sets the number of requests allowed in the rate limit window. The default is 5000.
+// Each token has its own quota.
+func (s *Server) SetRateLimit(limit int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.rateLimit = limit
+}
+
+// SetTokenRateLimit sets the number of requests allowed in the rate limit window for requests authenticated with the
+// token, overriding SetRateLimit.
+func (s *Server) SetTokenRateLimit(token string, limit int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tokenRateLimits[token] = limit
+}
+
+// quota returns the rate limit and the number of requests used by a token. The caller must hold the lock.
+func (s *Server) quota(token string) (int, int) {
+	limit, ok := s.tokenRateLimits[token]
+	if !ok {
+		limit = s.rateLimit
+	}
+	return limit, s.used[token]
+}
+
+func token(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// InjectFault makes the server fail requests. Faults are applied in the order they were injected.
+func (s *Server) InjectFault(fault Fault) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// Requests returns the number of requests received for the path.
+func (s *Server) Requests(path string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		s.requests[r.URL.Path]++
+		fault := s.fault(r.URL.Path)
+		// querying the rate limit doesn't count against the rate limit
+		if r.URL.Path != "/rate_limit" {
+			s.used[token(r)]++
+		}
+		limit, used := s.quota(token(r))
+		reset := s.reset
+		s.lock.Unlock()
+
+		remaining := max(0, limit-used)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Used", strconv.Itoa(min(used, limit)))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.Header().Set("X-RateLimit-Resource", "core")
+
+		switch {
+		case used > limit:
+			writeError(w, http.StatusForbidden, "API rate limit exceeded")
+		case fault != nil && fault.CloseConnection:
+			conn, _, err := http.NewResponseController(w).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+		case fault != nil:
+			writeError(w, fault.StatusCode, http.StatusText(fault.StatusCode))
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// fault returns the fault to apply to a request for the path, if any. The caller must hold the lock.
+func (s *Server) fault(path string) *Fault {
+	for i, fault := range s.faults {
+		if fault.Path != "" && fault.Path != path {
+			continue
+		}
+		if fault.Count > 0 {
+			if fault.Count--; fault.Count == 0 {
+				s.faults = slices.Delete(s.faults, i, i+1)
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+func (s *Server) listUserRepos(w http.ResponseWriter, r *http.Request) {
+	user := r.PathValue("user")
+	s.lock.Lock()
+	var repos []*github.Repository
+	for _, repo := range s.repos {
+		if repo.Owner == user {
+			repos = append(repos, repo.toGitHub())
+		}
+	}
+	s.lock.Unlock()
+	slices.SortFunc(repos, func(a, b *github.Repository) int { return strings.Compare(a.GetFullName(), b.GetFullName()) })
+	writeJSON(w, paginate(w, r, repos))
+}
+
+func (s *Server) listTeamRepos(w http.ResponseWriter, r *http.Request) {
+	org, team := r.PathValue("org"), r.PathValue("team")
+	s.lock.Lock()
+	var repos []*github.Repository
+	for _, repo := range s.repos {
+		if role, ok := repo.Teams[team]; ok && repo.Owner == org {
+			teamRepo := repo.toGitHub()
+			teamRepo.RoleName = new(role)
+			repos = append(repos, teamRepo)
+		}
+	}
+	s.lock.Unlock()
+	slices.SortFunc(repos, func(a, b *github.Repository) int { return strings.Compare(a.GetFullName(), b.GetFullName()) })
+	writeJSON(w, paginate(w, r, repos))
+}
+
+func (s *Server) listCustomPropertyValues(w http.ResponseWriter, r *http.Request) {
+	org := r.PathValue("org")
+	s.lock.Lock()
+	var values []*github.RepoCustomPropertyValue
+	for _, repo := range s.repos {
+		if repo.Owner != org {
+			continue
+		}
+		value := github.RepoCustomPropertyValue{RepositoryName: repo.Name, RepositoryFullName: repo.Owner + "/" + repo.Name}
+		for name, propertyValue := range repo.Properties {
+			value.Properties = append(value.Properties, &github.CustomPropertyValue{PropertyName: name, Value: propertyValue})
+		}
+		values = append(values, &value)
+	}
+	s.lock.Unlock()
+	if len(values) == 0 {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	slices.SortFunc(values, func(a, b *github.RepoCustomPropertyValue) int {
+		return strings.Compare(a.RepositoryFullName, b.RepositoryFullName)
+	})
+	writeJSON(w, paginate(w, r, values))
+}
+
+// searchRepos supports the org, topic and archived qualifiers. Other qualifiers and search terms are ignored.
+func (s *Server) searchRepos(w http.ResponseWriter, r *http.Request) {
+	qualifiers := make(map[string]string)
+	for _, term := range strings.Fields(r.URL.Query().Get("q")) {
+		if key, value, ok := strings.Cut(term, ":"); ok {
+			qualifiers[key] = value
+		}
+	}
+	s.lock.Lock()
+	var repos []*github.Repository
+	for _, repo := range s.repos {
+		if repo.matches(qualifiers) {
+			repos = append(repos, repo.toGitHub())
+		}
+	}
+	s.lock.Unlock()
+	slices.SortFunc(repos, func(a, b *github.Repository) int { return strings.Compare(a.GetFullName(), b.GetFullName()) })
+	writeJSON(w, github.RepositoriesSearchResult{
+		Total:             new(len(repos)),
+		IncompleteResults: new(false),
+		Repositories:      paginate(w, r, repos),
+	})
+}
+
+func (s *Server) getRepo(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.repo(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, repo.toGitHub())
+}
+
+func (s *Server) listPullRequests(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.repo(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = "open"
+	}
+	var pulls []*github.PullRequest
+	for _, pr := range repo.PullRequests {
+		if state == "all" || pr.Open == (state == "open") {
+			prState := "closed"
+			if pr.Open {
+				prState = "open"
+			}
+			pulls = append(pulls, &github.PullRequest{Number: new(pr.Number), State: new(prState)})
+		}
+	}
+	writeJSON(w, paginate(w, r, pulls))
+}
+
+func (s *Server) getStats(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.repo(r); !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	// statistics are always ready, but empty
+	writeJSON(w, []struct{}{})
+}
+
+func (s *Server) getBranchProtection(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.repo(r); !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeError(w, http.StatusNotFound, "Branch not protected")
+}
+
+func (s *Server) getRateLimit(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	limit, used := s.quota(token(r))
+	core := github.Rate{Limit: limit, Used: min(used, limit), Remaining: max(0, limit-used), Reset: github.Timestamp{Time: s.reset}}
+	s.lock.Unlock()
+	writeJSON(w, struct {
+		Resources github.RateLimits `json:"resources"`
+	}{Resources: github.RateLimits{Core: &core}})
+}
+
+func (s *Server) repo(r *http.Request) (Repo, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	repo, ok := s.repos[r.PathValue("owner")+"/"+r.PathValue("repo")]
+	return repo, ok
+}
+
+func (r Repo) matches(qualifiers map[string]string) bool {
+	if org, ok := qualifiers["org"]; ok && org != r.Owner {
+		return false
+	}
+	if topic, ok := qualifiers["topic"]; ok && !slices.Contains(r.Topics, topic) {
+		return false
+	}
+	if archived, ok := qualifiers["archived"]; ok && archived != strconv.FormatBool(r.Archived) {
+		return false
+	}
+	return true
+}
+
+func (r Repo) toGitHub() *github.Repository {
+	var openPullRequests int
+	for _, pr := range r.PullRequests {
+		if pr.Open {
+			openPullRequests++
+		}
+	}
+	return &github.Repository{
+		Owner:           &github.User{Login: new(r.Owner)},
+		Name:            new(r.Name),
+		FullName:        new(r.Owner + "/" + r.Name),
+		DefaultBranch:   new("main"),
+		StargazersCount: new(r.Stars),
+		ForksCount:      new(r.Forks),
+		OpenIssuesCount: new(r.OpenIssues + openPullRequests),
+		Archived:        new(r.Archived),
+		Topics:          r.Topics,
+	}
+}
+
+// paginate returns the requested page of items and sets the Link header to the next and last pages.
+func paginate[T any](w http.ResponseWriter, r *http.Request, items []T) []T {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if perPage <= 0 {
+		perPage = 30
+	}
+	lastPage := max(1, (len(items)+perPage-1)/perPage)
+
+	var links []string
+	if page < lastPage {
+		links = append(links, link(r, page+1, "next"), link(r, lastPage, "last"))
+	}
+	if page > 1 {
+		links = append(links, link(r, 1, "first"), link(r, page-1, "prev"))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	start := min(len(items), (page-1)*perPage)
+	end := min(len(items), start+perPage)
+	return items[start:end]
+}
+
+func link(r *http.Request, page int, rel string) string {
+	query := r.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	u := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(struct {
+		Message          string `json:"message"`
+		DocumentationURL string `json:"documentation_url"`
+	}{message, "https://docs.github.com/rest"})
+}
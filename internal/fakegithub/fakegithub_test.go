@@ -0,0 +1,161 @@
+package fakegithub_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/clambin/github-exporter/internal/fakegithub"
+	"github.com/clambin/github-exporter/internal/stats/github"
+	gogithub "github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Pagination(t *testing.T) {
+	var repos []fakegithub.Repo
+	for i := range 150 {
+		repos = append(repos, fakegithub.Repo{Owner: "foo", Name: fmt.Sprintf("repo-%03d", i)})
+	}
+	pulls := make([]fakegithub.PullRequest, 0, 250)
+	for i := range 250 {
+		pulls = append(pulls, fakegithub.PullRequest{Number: i + 1, Open: i%2 == 0})
+	}
+	repos[0].PullRequests = pulls
+	s := fakegithub.New(repos...)
+	t.Cleanup(s.Close)
+
+	c, err := github.New(http.DefaultTransport, s.BaseURL())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	names, err := c.GetUserRepoNames(ctx, "foo")
+	require.NoError(t, err)
+	assert.Len(t, names, 150)
+	assert.Equal(t, "foo/repo-000", names[0])
+	assert.Equal(t, "foo/repo-149", names[149])
+	assert.Equal(t, 2, s.Requests("/users/foo/repos"))
+
+	count, err := c.GetPullRequestCount(ctx, "foo", "repo-000")
+	require.NoError(t, err)
+	assert.Equal(t, 125, count)
+	assert.Equal(t, 2, s.Requests("/repos/foo/repo-000/pulls"))
+}
+
+func TestServer_Search(t *testing.T) {
+	var repos []fakegithub.Repo
+	for i := range 150 {
+		repos = append(repos, fakegithub.Repo{Owner: "foo", Name: fmt.Sprintf("repo-%03d", i), Topics: []string{"team-a"}})
+	}
+	repos = append(repos,
+		fakegithub.Repo{Owner: "foo", Name: "archived", Topics: []string{"team-a"}, Archived: true},
+		fakegithub.Repo{Owner: "foo", Name: "other", Topics: []string{"team-b"}},
+		fakegithub.Repo{Owner: "bar", Name: "repo", Topics: []string{"team-a"}},
+	)
+	s := fakegithub.New(repos...)
+	t.Cleanup(s.Close)
+
+	c, err := github.New(http.DefaultTransport, s.BaseURL())
+	require.NoError(t, err)
+
+	names, err := c.GetTopicRepoNames(context.Background(), "foo", "team-a")
+	require.NoError(t, err)
+	assert.Len(t, names, 150)
+	assert.Equal(t, "foo/repo-000", names[0])
+	assert.Equal(t, "foo/repo-149", names[149])
+	assert.Equal(t, 2, s.Requests("/search/repositories"))
+}
+
+func TestServer_Teams(t *testing.T) {
+	s := fakegithub.New(
+		fakegithub.Repo{Owner: "foo", Name: "bar", Teams: map[string]string{"payments": "admin", "platform": "read"}},
+		fakegithub.Repo{Owner: "foo", Name: "snafu", Teams: map[string]string{"platform": "write"}},
+		fakegithub.Repo{Owner: "bar", Name: "foo", Teams: map[string]string{"payments": "admin"}},
+	)
+	t.Cleanup(s.Close)
+
+	c, err := github.New(http.DefaultTransport, s.BaseURL())
+	require.NoError(t, err)
+
+	repos, err := c.GetTeamRepos(context.Background(), "foo", "platform")
+	require.NoError(t, err)
+	assert.Equal(t, []github.TeamRepo{
+		{Name: "foo/bar", TeamAccess: github.TeamAccess{Org: "foo", Team: "platform", Permission: "read"}},
+		{Name: "foo/snafu", TeamAccess: github.TeamAccess{Org: "foo", Team: "platform", Permission: "write"}},
+	}, repos)
+}
+
+func TestServer_CustomProperties(t *testing.T) {
+	s := fakegithub.New(
+		fakegithub.Repo{Owner: "foo", Name: "bar", Properties: map[string]string{"service-tier": "1"}},
+		fakegithub.Repo{Owner: "foo", Name: "snafu"},
+	)
+	t.Cleanup(s.Close)
+
+	c, err := github.New(http.DefaultTransport, s.BaseURL())
+	require.NoError(t, err)
+
+	properties, err := c.GetCustomProperties(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{"foo/bar": {"service-tier": "1"}, "foo/snafu": {}}, properties)
+
+	properties, err = c.GetCustomProperties(context.Background(), "bar")
+	require.NoError(t, err)
+	assert.Empty(t, properties)
+}
+
+func TestServer_Errors(t *testing.T) {
+	s := fakegithub.New(fakegithub.Repo{Owner: "foo", Name: "bar", Stars: 10, OpenIssues: 2})
+	t.Cleanup(s.Close)
+	c, err := github.New(http.DefaultTransport, s.BaseURL())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = c.GetRepoStats(ctx, "foo", "snafu")
+	assert.Equal(t, github.ErrorClassNotFound, github.ErrorClass(err))
+
+	s.InjectFault(fakegithub.Fault{Path: "/repos/foo/bar", StatusCode: http.StatusBadGateway, Count: 1})
+	_, err = c.GetRepoStats(ctx, "foo", "bar")
+	var errResponse *gogithub.ErrorResponse
+	require.ErrorAs(t, err, &errResponse)
+	assert.Equal(t, http.StatusBadGateway, errResponse.Response.StatusCode)
+	assert.Equal(t, "Bad Gateway", errResponse.Message)
+
+	repoStats, err := c.GetRepoStats(ctx, "foo", "bar")
+	require.NoError(t, err)
+	assert.Equal(t, github.RepoStats{Name: "bar", FullName: "foo/bar", DefaultBranch: "main", Stars: 10, Issues: 2}, repoStats)
+
+	// the transport transparently retries requests on reused connections, so use a new connection for each request
+	c, err = github.New(&http.Transport{DisableKeepAlives: true}, s.BaseURL())
+	require.NoError(t, err)
+	s.InjectFault(fakegithub.Fault{CloseConnection: true, Count: 1})
+	_, err = c.GetRepoStats(ctx, "foo", "bar")
+	assert.Error(t, err)
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	s := fakegithub.New(fakegithub.Repo{Owner: "foo", Name: "bar"})
+	t.Cleanup(s.Close)
+	s.SetRateLimit(1)
+	c, err := github.New(http.DefaultTransport, s.BaseURL())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = c.GetRepoStats(ctx, "foo", "bar")
+	require.NoError(t, err)
+	_, err = c.GetRepoStats(ctx, "foo", "bar")
+	var rateLimitErr *gogithub.RateLimitError
+	require.ErrorAs(t, err, &rateLimitErr)
+	assert.Equal(t, 1, rateLimitErr.Rate.Limit)
+	assert.Equal(t, github.ErrorClassRateLimited, github.ErrorClass(err))
+
+	// the quota can still be queried
+	quotas, err := c.GetQuotas(ctx)
+	require.NoError(t, err)
+	require.Len(t, quotas, 1)
+	assert.Equal(t, "core", quotas[0].Resource)
+	assert.Equal(t, 1, quotas[0].Used)
+	assert.Equal(t, 0, quotas[0].Remaining)
+	assert.True(t, rateLimitErr.Rate.Reset.Time.Equal(quotas[0].Reset))
+}
@@ -0,0 +1,16 @@
+// Package label names the Prometheus labels derived from GitHub data. It is shared by the collector, which reports the
+// labels, and the configuration, which validates them.
+package label
+
+// Property returns the label name of a custom property: the property name, with any characters that are not valid in a
+// label name replaced by underscores. E.g. the label of property service-tier is service_tier.
+func Property(property string) string {
+	label := []rune(property)
+	for i, r := range label {
+		valid := r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9'
+		if !valid {
+			label[i] = '_'
+		}
+	}
+	return string(label)
+}
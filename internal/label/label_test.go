@@ -0,0 +1,25 @@
+package label
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProperty(t *testing.T) {
+	tests := []struct {
+		property string
+		want     string
+	}{
+		{property: "service_tier", want: "service_tier"},
+		{property: "service-tier", want: "service_tier"},
+		{property: "Cost Center", want: "Cost_Center"},
+		{property: "1st-owner", want: "_st_owner"},
+		{property: "owner2", want: "owner2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			assert.Equal(t, tt.want, Property(tt.property))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// Config configures the OTLP exporter. If Endpoint is blank, the exporter uses the standard OTEL_EXPORTER_OTLP_*
+// environment variables.
+type Config struct {
+	Headers  map[string]string
+	Resource map[string]string
+	Protocol string
+	Endpoint string
+	Interval time.Duration
+	Insecure bool
+}
+
+// NewMeterProvider returns a MeterProvider that periodically exports the metrics of g over OTLP. The caller must call
+// Shutdown on the returned MeterProvider to flush the last metrics. Errors gathering the metrics are logged to logger.
+func NewMeterProvider(ctx context.Context, cfg Config, version string, g prometheus.Gatherer, logger *slog.Logger) (*sdkmetric.MeterProvider, error) {
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(NewResource(cfg.Resource, version)),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter,
+			sdkmetric.WithInterval(cfg.Interval),
+			sdkmetric.WithProducer(NewProducer(g, logger)),
+		)),
+	), nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	switch cfg.Protocol {
+	case "grpc":
+		var opts []otlpmetricgrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+	case "http":
+		var opts []otlpmetrichttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("invalid otlp protocol: %q", cfg.Protocol)
+	}
+}
+
+// NewResource returns the resource describing the exporter. attributes are added to the default service attributes
+// and may override them.
+func NewResource(attributes map[string]string, version string) *resource.Resource {
+	kvs := []attribute.KeyValue{
+		attribute.String("service.name", "github-exporter"),
+		attribute.String("service.version", version),
+	}
+	for key, value := range attributes {
+		kvs = append(kvs, attribute.String(key, value))
+	}
+	return resource.NewSchemaless(kvs...)
+}
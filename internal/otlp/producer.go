@@ -0,0 +1,199 @@
+package otlp
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var _ sdkmetric.Producer = &Producer{}
+
+// Producer bridges the metrics of a Prometheus Gatherer into OpenTelemetry. Metric and label names are mapped
+// to OpenTelemetry names: see mappings for the repo metrics. Other metrics are named github.<name>, with the
+// github_exporter_ prefix and any unit suffix removed.
+//
+// If the Gatherer reports an error, Produce logs it and still returns the gathered metrics: the SDK drops all
+// metrics of an export if the producer fails, so a single failing repo would otherwise lose all other metrics.
+type Producer struct {
+	start    time.Time
+	Gatherer prometheus.Gatherer
+	Logger   *slog.Logger
+}
+
+const scopeName = "github.com/clambin/github-exporter"
+
+func NewProducer(g prometheus.Gatherer, logger *slog.Logger) *Producer {
+	return &Producer{Gatherer: g, Logger: logger, start: time.Now()}
+}
+
+type mapping struct {
+	attributes []attribute.KeyValue
+	name       string
+	unit       string
+}
+
+// mappings maps the repo metrics to their OpenTelemetry name. Where possible, these follow the VCS semantic conventions.
+var mappings = map[string]mapping{
+	"github_exporter_pulls": {
+		name:       "vcs.change.count",
+		unit:       "{change}",
+		attributes: []attribute.KeyValue{attribute.String("vcs.change.state", "open")},
+	},
+	"github_exporter_stars":  {name: "github.repository.stars", unit: "{star}"},
+	"github_exporter_forks":  {name: "github.repository.forks", unit: "{fork}"},
+	"github_exporter_issues": {name: "github.repository.issues", unit: "{issue}"},
+}
+
+// attributeNames maps Prometheus label names to OpenTelemetry attribute names.
+var attributeNames = map[string]string{
+	"repo":     "vcs.repository.name",
+	"archived": "github.repository.archived",
+	"host":     "server.address",
+}
+
+// unitSuffixes maps Prometheus unit suffixes to their UCUM unit.
+var unitSuffixes = []struct {
+	suffix string
+	unit   string
+}{
+	{suffix: "_seconds", unit: "s"},
+	{suffix: "_bytes", unit: "By"},
+}
+
+func (p *Producer) Produce(_ context.Context) ([]metricdata.ScopeMetrics, error) {
+	mfs, err := p.Gatherer.Gather()
+	if err != nil {
+		p.Logger.Warn("failed to gather some metrics", "err", err)
+	}
+	if len(mfs) == 0 {
+		return nil, nil
+	}
+	now := time.Now()
+	metrics := make([]metricdata.Metrics, 0, len(mfs))
+	for _, mf := range mfs {
+		if m, ok := p.convert(mf, now); ok {
+			metrics = append(metrics, m)
+		}
+	}
+	return []metricdata.ScopeMetrics{{
+		Scope:   instrumentation.Scope{Name: scopeName},
+		Metrics: metrics,
+	}}, nil
+}
+
+func (p *Producer) convert(mf *dto.MetricFamily, now time.Time) (metricdata.Metrics, bool) {
+	m := metricName(mf)
+	result := metricdata.Metrics{Name: m.name, Description: mf.GetHelp(), Unit: m.unit}
+
+	switch mf.GetType() {
+	case dto.MetricType_COUNTER:
+		dps := make([]metricdata.DataPoint[float64], 0, len(mf.GetMetric()))
+		for _, metric := range mf.GetMetric() {
+			dps = append(dps, metricdata.DataPoint[float64]{
+				Attributes: attributes(metric, m.attributes),
+				StartTime:  p.start,
+				Time:       now,
+				Value:      metric.GetCounter().GetValue(),
+			})
+		}
+		result.Data = metricdata.Sum[float64]{DataPoints: dps, Temporality: metricdata.CumulativeTemporality, IsMonotonic: true}
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		dps := make([]metricdata.DataPoint[float64], 0, len(mf.GetMetric()))
+		for _, metric := range mf.GetMetric() {
+			value := metric.GetGauge().GetValue()
+			if mf.GetType() == dto.MetricType_UNTYPED {
+				value = metric.GetUntyped().GetValue()
+			}
+			dps = append(dps, metricdata.DataPoint[float64]{
+				Attributes: attributes(metric, m.attributes),
+				Time:       now,
+				Value:      value,
+			})
+		}
+		result.Data = metricdata.Gauge[float64]{DataPoints: dps}
+	case dto.MetricType_HISTOGRAM:
+		dps := make([]metricdata.HistogramDataPoint[float64], 0, len(mf.GetMetric()))
+		for _, metric := range mf.GetMetric() {
+			h := metric.GetHistogram()
+			bounds := make([]float64, 0, len(h.GetBucket()))
+			counts := make([]uint64, 0, len(h.GetBucket())+1)
+			var previous uint64
+			for _, b := range h.GetBucket() {
+				bounds = append(bounds, b.GetUpperBound())
+				counts = append(counts, b.GetCumulativeCount()-previous)
+				previous = b.GetCumulativeCount()
+			}
+			counts = append(counts, h.GetSampleCount()-previous)
+			dps = append(dps, metricdata.HistogramDataPoint[float64]{
+				Attributes:   attributes(metric, m.attributes),
+				StartTime:    p.start,
+				Time:         now,
+				Count:        h.GetSampleCount(),
+				Bounds:       bounds,
+				BucketCounts: counts,
+				Sum:          h.GetSampleSum(),
+			})
+		}
+		result.Data = metricdata.Histogram[float64]{DataPoints: dps, Temporality: metricdata.CumulativeTemporality}
+	case dto.MetricType_SUMMARY:
+		dps := make([]metricdata.SummaryDataPoint, 0, len(mf.GetMetric()))
+		for _, metric := range mf.GetMetric() {
+			s := metric.GetSummary()
+			quantiles := make([]metricdata.QuantileValue, 0, len(s.GetQuantile()))
+			for _, q := range s.GetQuantile() {
+				quantiles = append(quantiles, metricdata.QuantileValue{Quantile: q.GetQuantile(), Value: q.GetValue()})
+			}
+			dps = append(dps, metricdata.SummaryDataPoint{
+				Attributes:     attributes(metric, m.attributes),
+				StartTime:      p.start,
+				Time:           now,
+				Count:          s.GetSampleCount(),
+				Sum:            s.GetSampleSum(),
+				QuantileValues: quantiles,
+			})
+		}
+		result.Data = metricdata.Summary{DataPoints: dps}
+	default:
+		return result, false
+	}
+	return result, true
+}
+
+func metricName(mf *dto.MetricFamily) mapping {
+	if m, ok := mappings[mf.GetName()]; ok {
+		return m
+	}
+	name := strings.TrimPrefix(mf.GetName(), "github_exporter_")
+	if mf.GetType() == dto.MetricType_COUNTER {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	var unit string
+	for _, s := range unitSuffixes {
+		if strings.HasSuffix(name, s.suffix) {
+			name, unit = strings.TrimSuffix(name, s.suffix), s.unit
+			break
+		}
+	}
+	return mapping{name: "github." + name, unit: unit}
+}
+
+func attributes(metric *dto.Metric, extra []attribute.KeyValue) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(metric.GetLabel())+len(extra))
+	for _, label := range metric.GetLabel() {
+		name := label.GetName()
+		if mapped, ok := attributeNames[name]; ok {
+			name = mapped
+		}
+		kvs = append(kvs, attribute.String(name, label.GetValue()))
+	}
+	kvs = append(kvs, extra...)
+	return attribute.NewSet(kvs...)
+}
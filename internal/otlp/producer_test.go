@@ -0,0 +1,98 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestProducer_Produce(t *testing.T) {
+	r := prometheus.NewRegistry()
+	pulls := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "github_exporter_pulls", Help: "Total number of open pull requests"}, []string{"host", "repo", "archived"})
+	pulls.WithLabelValues("github.com", "foo/bar", "false").Set(5)
+	refreshes := prometheus.NewCounter(prometheus.CounterOpts{Name: "github_exporter_refresh_total", Help: "refreshes"})
+	refreshes.Add(2)
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "github_exporter_refresh_duration_seconds", Help: "duration", Buckets: []float64{1, 10}})
+	duration.Observe(0.5)
+	duration.Observe(5)
+	duration.Observe(50)
+	r.MustRegister(pulls, refreshes, duration)
+
+	reader := sdkmetric.NewManualReader(sdkmetric.WithProducer(NewProducer(r, slog.Default())))
+	_ = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithResource(NewResource(map[string]string{"deployment.environment": "test"}, "v1")))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	v, ok := rm.Resource.Set().Value("deployment.environment")
+	assert.True(t, ok)
+	assert.Equal(t, "test", v.AsString())
+	v, _ = rm.Resource.Set().Value("service.name")
+	assert.Equal(t, "github-exporter", v.AsString())
+
+	require.Len(t, rm.ScopeMetrics, 1)
+	metrics := make(map[string]metricdata.Metrics)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+
+	m, ok := metrics["vcs.change.count"]
+	require.True(t, ok)
+	assert.Equal(t, "{change}", m.Unit)
+	gauge := m.Data.(metricdata.Gauge[float64])
+	require.Len(t, gauge.DataPoints, 1)
+	assert.Equal(t, 5.0, gauge.DataPoints[0].Value)
+	assert.Equal(t, attribute.NewSet(
+		attribute.String("server.address", "github.com"),
+		attribute.String("vcs.repository.name", "foo/bar"),
+		attribute.String("github.repository.archived", "false"),
+		attribute.String("vcs.change.state", "open"),
+	), gauge.DataPoints[0].Attributes)
+
+	m, ok = metrics["github.refresh"]
+	require.True(t, ok)
+	sum := m.Data.(metricdata.Sum[float64])
+	assert.True(t, sum.IsMonotonic)
+	assert.Equal(t, 2.0, sum.DataPoints[0].Value)
+
+	m, ok = metrics["github.refresh_duration"]
+	require.True(t, ok)
+	assert.Equal(t, "s", m.Unit)
+	histogram := m.Data.(metricdata.Histogram[float64])
+	assert.Equal(t, []float64{1, 10}, histogram.DataPoints[0].Bounds)
+	assert.Equal(t, []uint64{1, 1, 1}, histogram.DataPoints[0].BucketCounts)
+	assert.Equal(t, uint64(3), histogram.DataPoints[0].Count)
+}
+
+func TestProducer_Produce_GatherError(t *testing.T) {
+	r := prometheus.NewRegistry()
+	stars := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "github_exporter_stars", Help: "Total number of stars"}, []string{"repo"})
+	stars.WithLabelValues("foo/bar").Set(10)
+	r.MustRegister(stars)
+	// a repo failed: the other metrics are still gathered
+	g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := r.Gather()
+		require.NoError(t, err)
+		return mfs, errors.New("foo/snafu: failed")
+	})
+
+	reader := sdkmetric.NewManualReader(sdkmetric.WithProducer(NewProducer(g, slog.Default())))
+	_ = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	m := rm.ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "github.repository.stars", m.Name)
+	assert.Equal(t, 10.0, m.Data.(metricdata.Gauge[float64]).DataPoints[0].Value)
+}
@@ -0,0 +1,134 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/clambin/github-exporter/retry"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var _ prometheus.Collector = &Pusher{}
+
+// A Target receives the metrics gathered by a Pusher.
+type Target interface {
+	Push(context.Context, []*dto.MetricFamily) error
+}
+
+// Pusher periodically pushes the metrics of a Gatherer to a Target. Failed pushes are retried with exponential backoff.
+type Pusher struct {
+	lastSuccess time.Time
+	Target      Target
+	Gatherer    prometheus.Gatherer
+	Logger      *slog.Logger
+	Interval    time.Duration
+	Backoff     time.Duration
+	Retries     int
+	successes   int
+	failures    int
+	retries     int
+	lock        sync.Mutex
+}
+
+var (
+	pushTotalMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("github", "exporter", "push_total"),
+		"Total number of metric pushes",
+		[]string{"result"},
+		nil,
+	)
+	pushRetriesMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("github", "exporter", "push_retries_total"),
+		"Total number of retried metric pushes",
+		nil,
+		nil,
+	)
+	pushLastSuccessMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("github", "exporter", "push_last_success_timestamp_seconds"),
+		"Time of the last successful metric push",
+		nil,
+		nil,
+	)
+)
+
+// Run pushes metrics every Interval, until ctx is cancelled.
+func (p *Pusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Push(ctx); err != nil && ctx.Err() == nil {
+			p.Logger.Error("failed to push metrics", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Push gathers the metrics and pushes them to the Target. If some metrics could not be gathered, the remaining metrics
+// are still pushed. If the push fails, it is retried up to Retries times, without gathering the metrics again.
+func (p *Pusher) Push(ctx context.Context) error {
+	mfs, err := p.Gatherer.Gather()
+	if err != nil {
+		if len(mfs) == 0 {
+			err = fmt.Errorf("gather: %w", err)
+			p.record(err, 0)
+			return err
+		}
+		p.Logger.Warn("failed to gather some metrics", "err", err)
+	}
+	backoff := p.Backoff
+	for attempt := 0; ; attempt++ {
+		err = p.Target.Push(ctx, mfs)
+		p.record(err, attempt)
+		if err == nil || attempt >= p.Retries {
+			return err
+		}
+		p.Logger.Debug("push failed. retrying", "err", err, "attempt", attempt+1, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retry.Jitter(backoff)):
+		}
+		backoff *= 2
+	}
+}
+
+func (p *Pusher) record(err error, attempt int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if attempt > 0 {
+		p.retries++
+	}
+	if err != nil {
+		p.failures++
+		return
+	}
+	p.successes++
+	p.lastSuccess = time.Now()
+}
+
+func (p *Pusher) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pushTotalMetric
+	ch <- pushRetriesMetric
+	ch <- pushLastSuccessMetric
+}
+
+func (p *Pusher) Collect(ch chan<- prometheus.Metric) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	ch <- prometheus.MustNewConstMetric(pushTotalMetric, prometheus.CounterValue, float64(p.successes), "success")
+	ch <- prometheus.MustNewConstMetric(pushTotalMetric, prometheus.CounterValue, float64(p.failures), "failure")
+	ch <- prometheus.MustNewConstMetric(pushRetriesMetric, prometheus.CounterValue, float64(p.retries))
+	var lastSuccess float64
+	if !p.lastSuccess.IsZero() {
+		lastSuccess = float64(p.lastSuccess.Unix())
+	}
+	ch <- prometheus.MustNewConstMetric(pushLastSuccessMetric, prometheus.GaugeValue, lastSuccess)
+}
@@ -0,0 +1,142 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPusher_Push(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		retries  int
+		wantErr  assert.ErrorAssertionFunc
+		want     string
+	}{
+		{
+			name:    "success",
+			wantErr: assert.NoError,
+			want: `
+# HELP github_exporter_push_retries_total Total number of retried metric pushes
+# TYPE github_exporter_push_retries_total counter
+github_exporter_push_retries_total 0
+# HELP github_exporter_push_total Total number of metric pushes
+# TYPE github_exporter_push_total counter
+github_exporter_push_total{result="failure"} 0
+github_exporter_push_total{result="success"} 1
+`,
+		},
+		{
+			name:     "retried",
+			failures: 2,
+			retries:  2,
+			wantErr:  assert.NoError,
+			want: `
+# HELP github_exporter_push_retries_total Total number of retried metric pushes
+# TYPE github_exporter_push_retries_total counter
+github_exporter_push_retries_total 2
+# HELP github_exporter_push_total Total number of metric pushes
+# TYPE github_exporter_push_total counter
+github_exporter_push_total{result="failure"} 2
+github_exporter_push_total{result="success"} 1
+`,
+		},
+		{
+			name:     "failed",
+			failures: 3,
+			retries:  1,
+			wantErr:  assert.Error,
+			want: `
+# HELP github_exporter_push_retries_total Total number of retried metric pushes
+# TYPE github_exporter_push_retries_total counter
+github_exporter_push_retries_total 1
+# HELP github_exporter_push_total Total number of metric pushes
+# TYPE github_exporter_push_total counter
+github_exporter_push_total{result="failure"} 2
+github_exporter_push_total{result="success"} 0
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Pusher{
+				Target:   &fakeTarget{failures: tt.failures},
+				Gatherer: prometheus.NewRegistry(),
+				Logger:   slog.Default(),
+				Backoff:  time.Millisecond,
+				Retries:  tt.retries,
+			}
+			tt.wantErr(t, p.Push(context.Background()))
+			assert.NoError(t, testutil.CollectAndCompare(&p, strings.NewReader(tt.want), "github_exporter_push_total", "github_exporter_push_retries_total"))
+		})
+	}
+}
+
+func TestPusher_Push_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := Pusher{
+		Target:   &fakeTarget{failures: 1},
+		Gatherer: prometheus.NewRegistry(),
+		Logger:   slog.Default(),
+		Backoff:  time.Hour,
+		Retries:  5,
+	}
+	assert.ErrorIs(t, p.Push(ctx), context.Canceled)
+}
+
+func TestPusher_Push_GatherError(t *testing.T) {
+	r := prometheus.NewRegistry()
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "foo", Help: "foo"})
+	r.MustRegister(g)
+	var gathered int
+	target := fakeTarget{failures: 1}
+	p := Pusher{
+		Target: &target,
+		// a repo failed: the other metrics are still gathered
+		Gatherer: prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			gathered++
+			mfs, _ := r.Gather()
+			return mfs, errors.New("foo/snafu: failed")
+		}),
+		Logger:  slog.Default(),
+		Backoff: time.Millisecond,
+		Retries: 1,
+	}
+	assert.NoError(t, p.Push(context.Background()))
+	// the retry pushes the same metrics, without gathering them again
+	assert.Equal(t, 1, gathered)
+	require.Len(t, target.pushed, 1)
+	assert.Equal(t, "foo", target.pushed[0].GetName())
+
+	// nothing was gathered: nothing to push
+	p.Gatherer = prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return nil, errors.New("failed") })
+	assert.ErrorContains(t, p.Push(context.Background()), "gather: failed")
+}
+
+var _ Target = &fakeTarget{}
+
+type fakeTarget struct {
+	pushed   []*dto.MetricFamily
+	failures int
+}
+
+func (f *fakeTarget) Push(_ context.Context, mfs []*dto.MetricFamily) error {
+	f.pushed = mfs
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("push failed")
+	}
+	return nil
+}
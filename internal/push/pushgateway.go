@@ -0,0 +1,37 @@
+package push
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var _ Target = Pushgateway{}
+
+// Pushgateway pushes metrics to a Prometheus Pushgateway. Each push replaces all metrics of the job's grouping key.
+type Pushgateway struct {
+	Grouping   map[string]string
+	HTTPClient *http.Client
+	URL        string
+	Job        string
+	Username   string
+	Password   string
+}
+
+func (p Pushgateway) Push(ctx context.Context, mfs []*dto.MetricFamily) error {
+	g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return mfs, nil })
+	pusher := push.New(p.URL, p.Job).Gatherer(g)
+	for name, value := range p.Grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	if p.Username != "" {
+		pusher = pusher.BasicAuth(p.Username, p.Password)
+	}
+	if p.HTTPClient != nil {
+		pusher = pusher.Client(p.HTTPClient)
+	}
+	return pusher.PushContext(ctx)
+}
@@ -0,0 +1,46 @@
+package push
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushgateway_Push(t *testing.T) {
+	var method, path, user, password, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		user, password, _ = r.BasicAuth()
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+
+	r := prometheus.NewRegistry()
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "foo", Help: "foo"})
+	g.Set(1)
+	r.MustRegister(g)
+	mfs, err := r.Gather()
+	require.NoError(t, err)
+
+	p := Pushgateway{
+		URL:      ts.URL,
+		Job:      "github-exporter",
+		Grouping: map[string]string{"instance": "test"},
+		Username: "user",
+		Password: "password",
+	}
+	require.NoError(t, p.Push(context.Background(), mfs))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/github-exporter/instance/test", path)
+	assert.Equal(t, "user", user)
+	assert.Equal(t, "password", password)
+	assert.NotEmpty(t, body)
+}
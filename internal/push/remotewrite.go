@@ -0,0 +1,162 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var _ Target = RemoteWrite{}
+
+// RemoteWrite sends metrics to a Prometheus remote-write (v1) receiver. Labels holds additional labels added to each
+// series, e.g. a job label. If a series already has a label with the same name, the series' label is kept.
+type RemoteWrite struct {
+	Labels     map[string]string
+	HTTPClient *http.Client
+	URL        string
+	Username   string
+	Password   string
+}
+
+func (r RemoteWrite) Push(ctx context.Context, mfs []*dto.MetricFamily) error {
+	body := snappy.Encode(nil, encodeWriteRequest(toTimeSeries(mfs, r.Labels, time.Now())))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if r.Username != "" {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type timeSeries struct {
+	labels    []label
+	value     float64
+	timestamp int64
+}
+
+// toTimeSeries converts the metric families to remote-write time series. Histograms and summaries are split into
+// their _sum, _count and _bucket/quantile series.
+func toTimeSeries(mfs []*dto.MetricFamily, extraLabels map[string]string, now time.Time) []timeSeries {
+	ts := now.UnixMilli()
+	var series []timeSeries
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			add := func(name string, value float64, extra ...label) {
+				labels := make([]label, 0, len(m.GetLabel())+len(extraLabels)+len(extra)+1)
+				labels = append(labels, label{name: "__name__", value: name})
+				for _, l := range m.GetLabel() {
+					labels = append(labels, label{name: l.GetName(), value: l.GetValue()})
+				}
+				for labelName, labelValue := range extraLabels {
+					// receivers reject series with duplicate label names
+					if !slices.ContainsFunc(m.GetLabel(), func(l *dto.LabelPair) bool { return l.GetName() == labelName }) {
+						labels = append(labels, label{name: labelName, value: labelValue})
+					}
+				}
+				labels = append(labels, extra...)
+				slices.SortFunc(labels, func(a, b label) int { return strings.Compare(a.name, b.name) })
+				series = append(series, timeSeries{labels: labels, value: value, timestamp: ts})
+			}
+
+			name := mf.GetName()
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m.GetGauge().GetValue())
+			case dto.MetricType_SUMMARY:
+				add(name+"_sum", m.GetSummary().GetSampleSum())
+				add(name+"_count", float64(m.GetSummary().GetSampleCount()))
+				for _, q := range m.GetSummary().GetQuantile() {
+					add(name, q.GetValue(), label{name: "quantile", value: formatFloat(q.GetQuantile())})
+				}
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				add(name+"_sum", h.GetSampleSum())
+				add(name+"_count", float64(h.GetSampleCount()))
+				for _, b := range h.GetBucket() {
+					add(name+"_bucket", float64(b.GetCumulativeCount()), label{name: "le", value: formatFloat(b.GetUpperBound())})
+				}
+				add(name+"_bucket", float64(h.GetSampleCount()), label{name: "le", value: "+Inf"})
+			default:
+				add(name, m.GetUntyped().GetValue())
+			}
+		}
+	}
+	return series
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// encodeWriteRequest encodes the time series as a prometheus.WriteRequest protobuf message:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []timeSeries) []byte {
+	var b []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+		var sb []byte
+		sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+		sb = protowire.AppendFixed64(sb, math.Float64bits(s.value))
+		sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+		sb = protowire.AppendVarint(sb, uint64(s.timestamp))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sb)
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts)
+	}
+	return b
+}
@@ -0,0 +1,138 @@
+package push
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestRemoteWrite_Push(t *testing.T) {
+	var got []timeSeries
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "snappy" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		compressed, _ := io.ReadAll(r.Body)
+		body, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got = decodeWriteRequest(t, body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(ts.Close)
+
+	r := prometheus.NewRegistry()
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "foo", Help: "foo"}, []string{"repo"})
+	g.WithLabelValues("foo/bar").Set(10)
+	r.MustRegister(g)
+	mfs, err := r.Gather()
+	require.NoError(t, err)
+
+	rw := RemoteWrite{URL: ts.URL, Labels: map[string]string{"job": "github-exporter"}}
+	require.NoError(t, rw.Push(context.Background(), mfs))
+	require.Len(t, got, 1)
+	assert.Equal(t, []label{{"__name__", "foo"}, {"job", "github-exporter"}, {"repo", "foo/bar"}}, got[0].labels)
+	assert.Equal(t, 10.0, got[0].value)
+
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	})
+	assert.ErrorContains(t, rw.Push(context.Background(), mfs), "out of order sample")
+}
+
+func TestToTimeSeries(t *testing.T) {
+	r := prometheus.NewRegistry()
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration", Help: "duration", Buckets: []float64{1}})
+	h.Observe(0.5)
+	h.Observe(2)
+	r.MustRegister(h)
+	mfs, err := r.Gather()
+	require.NoError(t, err)
+
+	now := time.Unix(100, 0)
+	got := toTimeSeries(mfs, nil, now)
+	want := []timeSeries{
+		{labels: []label{{"__name__", "duration_sum"}}, value: 2.5, timestamp: 100_000},
+		{labels: []label{{"__name__", "duration_count"}}, value: 2, timestamp: 100_000},
+		{labels: []label{{"__name__", "duration_bucket"}, {"le", "1"}}, value: 1, timestamp: 100_000},
+		{labels: []label{{"__name__", "duration_bucket"}, {"le", "+Inf"}}, value: 2, timestamp: 100_000},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestToTimeSeries_LabelCollision(t *testing.T) {
+	r := prometheus.NewRegistry()
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "foo", Help: "foo"}, []string{"job", "repo"})
+	g.WithLabelValues("backfill", "foo/bar").Set(1)
+	r.MustRegister(g)
+	mfs, err := r.Gather()
+	require.NoError(t, err)
+
+	// the series' job label is kept
+	got := toTimeSeries(mfs, map[string]string{"job": "github-exporter", "instance": "test"}, time.Unix(100, 0))
+	want := []timeSeries{
+		{labels: []label{{"__name__", "foo"}, {"instance", "test"}, {"job", "backfill"}, {"repo", "foo/bar"}}, value: 1, timestamp: 100_000},
+	}
+	assert.Equal(t, want, got)
+}
+
+func decodeWriteRequest(t *testing.T, b []byte) []timeSeries {
+	t.Helper()
+	var series []timeSeries
+	forEachField(t, b, func(_ protowire.Number, v []byte) {
+		var s timeSeries
+		forEachField(t, v, func(num protowire.Number, v []byte) {
+			switch num {
+			case 1:
+				var l label
+				forEachField(t, v, func(num protowire.Number, v []byte) {
+					if num == 1 {
+						l.name = string(v)
+					} else {
+						l.value = string(v)
+					}
+				})
+				s.labels = append(s.labels, l)
+			case 2:
+				num, _, n := protowire.ConsumeTag(v)
+				require.Equal(t, protowire.Number(1), num)
+				v = v[n:]
+				bits, n := protowire.ConsumeFixed64(v)
+				s.value = math.Float64frombits(bits)
+				v = v[n:]
+				_, _, n = protowire.ConsumeTag(v)
+				ts, _ := protowire.ConsumeVarint(v[n:])
+				s.timestamp = int64(ts)
+			}
+		})
+		series = append(series, s)
+	})
+	return series
+}
+
+func forEachField(t *testing.T, b []byte, f func(protowire.Number, []byte)) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		require.Equal(t, protowire.BytesType, typ)
+		b = b[n:]
+		v, n := protowire.ConsumeBytes(b)
+		require.GreaterOrEqual(t, n, 0)
+		f(num, v)
+		b = b[n:]
+	}
+}
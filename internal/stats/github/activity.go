@@ -0,0 +1,113 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+)
+
+// Activity holds the commit and contributor activity of a repo.
+type Activity struct {
+	// Commits, Additions and Deletions cover the last complete week.
+	Commits   int
+	Additions int
+	Deletions int
+	// Contributors4w and Contributors52w hold the number of distinct authors that committed in the last 4 and 52 weeks.
+	Contributors4w  int
+	Contributors52w int
+}
+
+// ErrStatsNotReady is returned when GitHub is still computing a repo's statistics after all attempts.
+var ErrStatsNotReady = errors.New("repo statistics not ready")
+
+const (
+	defaultStatsAttempts = 4
+	defaultStatsBackoff  = 3 * time.Second
+)
+
+// GetActivity returns the commit and contributor activity of a repo.
+//
+// GitHub computes these statistics in the background and returns 202 Accepted until they are ready.
+// GetActivity retries these calls up to StatsAttempts times, waiting StatsBackoff between attempts.
+// If the statistics are still not ready, it returns ErrStatsNotReady.
+func (c Client) GetActivity(ctx context.Context, user string, repo string) (Activity, error) {
+	commitActivity, err := whenReady(ctx, c, func() ([]*github.WeeklyCommitActivity, *github.Response, error) {
+		return c.ListCommitActivity(ctx, user, repo)
+	})
+	if err != nil {
+		return Activity{}, err
+	}
+	contributorStats, err := whenReady(ctx, c, func() ([]*github.ContributorStats, *github.Response, error) {
+		return c.ListContributorsStats(ctx, user, repo)
+	})
+	if err != nil {
+		return Activity{}, err
+	}
+	return activity(commitActivity, contributorStats, time.Now()), nil
+}
+
+// whenReady calls f until GitHub no longer returns 202 Accepted.
+func whenReady[T any](ctx context.Context, c Client, f func() (T, *github.Response, error)) (T, error) {
+	attempts := c.StatsAttempts
+	if attempts <= 0 {
+		attempts = defaultStatsAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		result, _, err := f()
+		var acceptedErr *github.AcceptedError
+		if !errors.As(err, &acceptedErr) {
+			return result, err
+		}
+		if attempt >= attempts {
+			return result, ErrStatsNotReady
+		}
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(c.StatsBackoff):
+		}
+	}
+}
+
+// activity aggregates the weekly statistics reported by GitHub. GitHub weeks start on Sunday, 00:00 UTC.
+func activity(commitActivity []*github.WeeklyCommitActivity, contributorStats []*github.ContributorStats, now time.Time) Activity {
+	lastWeek := weekStart(now).AddDate(0, 0, -7)
+
+	var a Activity
+	for _, week := range commitActivity {
+		if week.GetWeek().Time.Equal(lastWeek) {
+			a.Commits = week.GetTotal()
+		}
+	}
+	for _, contributor := range contributorStats {
+		var active4w, active52w bool
+		for _, week := range contributor.Weeks {
+			start := week.GetWeek().Time
+			if start.Equal(lastWeek) {
+				a.Additions += week.GetAdditions()
+				a.Deletions += week.GetDeletions()
+			}
+			if week.GetCommits() == 0 {
+				continue
+			}
+			active4w = active4w || !start.Before(lastWeek.AddDate(0, 0, -7*3))
+			active52w = active52w || !start.Before(lastWeek.AddDate(0, 0, -7*51))
+		}
+		if active4w {
+			a.Contributors4w++
+		}
+		if active52w {
+			a.Contributors52w++
+		}
+	}
+	return a
+}
+
+// weekStart returns the start of the week of t, i.e. the previous Sunday, 00:00 UTC.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -int(day.Weekday()))
+}
@@ -0,0 +1,99 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetActivity(t *testing.T) {
+	lastWeek := weekStart(time.Now()).AddDate(0, 0, -7)
+
+	tests := []struct {
+		name     string
+		accepted int
+		wantErr  assert.ErrorAssertionFunc
+		want     Activity
+	}{
+		{
+			name:    "ready",
+			wantErr: assert.NoError,
+			want:    Activity{Commits: 5, Additions: 100, Deletions: 10, Contributors4w: 1, Contributors52w: 1},
+		},
+		{
+			name:     "computing",
+			accepted: 2,
+			wantErr:  assert.NoError,
+			want:     Activity{Commits: 5, Additions: 100, Deletions: 10, Contributors4w: 1, Contributors52w: 1},
+		},
+		{
+			name:     "not ready",
+			accepted: 10,
+			wantErr:  assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := New(http.DefaultTransport, "")
+			c.StatsBackoff = time.Millisecond
+			c.Repositories = fakeRepositories{
+				accepted: &tt.accepted,
+				commitActivity: []*github.WeeklyCommitActivity{
+					{Week: &github.Timestamp{Time: lastWeek}, Total: github.Ptr(5)},
+				},
+				contributorStats: []*github.ContributorStats{
+					{Weeks: []*github.WeeklyStats{
+						{Week: &github.Timestamp{Time: lastWeek}, Commits: github.Ptr(5), Additions: github.Ptr(100), Deletions: github.Ptr(10)},
+					}},
+				},
+			}
+			activity, err := c.GetActivity(context.Background(), "foo", "bar")
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, activity)
+		})
+	}
+}
+
+func Test_activity(t *testing.T) {
+	now := time.Date(2024, time.March, 13, 12, 0, 0, 0, time.UTC) // a Wednesday
+	week := func(weeksAgo int) *github.Timestamp {
+		return &github.Timestamp{Time: time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -7*weeksAgo)}
+	}
+
+	commitActivity := []*github.WeeklyCommitActivity{
+		{Week: week(2), Total: github.Ptr(7)},
+		{Week: week(1), Total: github.Ptr(5)},
+		{Week: week(0), Total: github.Ptr(1)},
+	}
+	contributorStats := []*github.ContributorStats{
+		{Weeks: []*github.WeeklyStats{
+			{Week: week(1), Commits: github.Ptr(3), Additions: github.Ptr(100), Deletions: github.Ptr(10)},
+		}},
+		{Weeks: []*github.WeeklyStats{
+			{Week: week(1), Commits: github.Ptr(2), Additions: github.Ptr(20), Deletions: github.Ptr(5)},
+			{Week: week(0), Commits: github.Ptr(1), Additions: github.Ptr(1), Deletions: github.Ptr(1)},
+		}},
+		{Weeks: []*github.WeeklyStats{
+			{Week: week(10), Commits: github.Ptr(1)},
+			{Week: week(1), Commits: github.Ptr(0)},
+		}},
+		{Weeks: []*github.WeeklyStats{
+			{Week: week(60), Commits: github.Ptr(1)},
+		}},
+	}
+
+	want := Activity{Commits: 5, Additions: 120, Deletions: 15, Contributors4w: 2, Contributors52w: 3}
+	assert.Equal(t, want, activity(commitActivity, contributorStats, now))
+}
+
+func Test_weekStart(t *testing.T) {
+	want := time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, want, weekStart(time.Date(2024, time.March, 13, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, want, weekStart(time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, want, weekStart(time.Date(2024, time.March, 16, 23, 59, 0, 0, time.UTC)))
+}
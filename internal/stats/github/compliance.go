@@ -0,0 +1,43 @@
+package github
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-github/v89/github"
+)
+
+// BranchProtection holds the protection rules of a branch.
+type BranchProtection struct {
+	Protected            bool
+	RequiredReviews      bool
+	RequiredStatusChecks bool
+	SignedCommits        bool
+	ForcePushAllowed     bool
+}
+
+// ErrProtectionUnavailable is returned when the protection rules of a branch can't be read. GitHub responds with
+// 403 Forbidden or 404 Not Found if the token doesn't have admin access to the repo.
+var ErrProtectionUnavailable = errors.New("branch protection unavailable: token requires admin access")
+
+// GetProtection returns the protection rules of a branch. If the branch is not protected, all rules are false.
+// Reading the protection rules requires admin access to the repo: without it, GetProtection returns ErrProtectionUnavailable.
+func (c Client) GetProtection(ctx context.Context, user string, repo string, branch string) (BranchProtection, error) {
+	p, _, err := c.GetBranchProtection(ctx, user, repo, branch)
+	if errors.Is(err, github.ErrBranchNotProtected) {
+		return BranchProtection{}, nil
+	}
+	if err != nil {
+		if class := ErrorClass(err); class == ErrorClassForbidden || class == ErrorClassNotFound {
+			return BranchProtection{}, ErrProtectionUnavailable
+		}
+		return BranchProtection{}, err
+	}
+	return BranchProtection{
+		Protected:            true,
+		RequiredReviews:      p.GetRequiredPullRequestReviews() != nil,
+		RequiredStatusChecks: p.GetRequiredStatusChecks() != nil,
+		SignedCommits:        p.GetRequiredSignatures().GetEnabled(),
+		ForcePushAllowed:     p.GetAllowForcePushes() != nil && p.GetAllowForcePushes().Enabled,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetProtection(t *testing.T) {
+	tests := []struct {
+		name       string
+		protection map[string]*github.Protection
+		wantErr    assert.ErrorAssertionFunc
+		want       BranchProtection
+	}{
+		{
+			name: "protected",
+			protection: map[string]*github.Protection{"main": {
+				RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{RequiredApprovingReviewCount: 1},
+				RequiredStatusChecks:       &github.RequiredStatusChecks{Strict: true},
+				RequiredSignatures:         &github.SignaturesProtectedBranch{Enabled: new(true)},
+				AllowForcePushes:           &github.AllowForcePushes{Enabled: true},
+			}},
+			wantErr: assert.NoError,
+			want:    BranchProtection{Protected: true, RequiredReviews: true, RequiredStatusChecks: true, SignedCommits: true, ForcePushAllowed: true},
+		},
+		{
+			name:       "minimal",
+			protection: map[string]*github.Protection{"main": {}},
+			wantErr:    assert.NoError,
+			want:       BranchProtection{Protected: true},
+		},
+		{
+			name:       "not protected",
+			protection: map[string]*github.Protection{},
+			wantErr:    assert.NoError,
+		},
+		{
+			name:    "error",
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := New(http.DefaultTransport, "")
+			c.Repositories = fakeRepositories{protection: tt.protection}
+			protection, err := c.GetProtection(context.Background(), "foo", "bar", "main")
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, protection)
+		})
+	}
+}
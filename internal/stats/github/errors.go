@@ -0,0 +1,44 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/google/go-github/v89/github"
+)
+
+// Error classes returned by ErrorClass.
+const (
+	ErrorClassNotFound    = "not_found"
+	ErrorClassRateLimited = "rate_limited"
+	ErrorClassForbidden   = "forbidden"
+	ErrorClassTimeout     = "timeout"
+	ErrorClassOther       = "other"
+)
+
+// ErrorClass classifies an error returned by the GitHub API.
+func ErrorClass(err error) string {
+	var rateLimitErr *github.RateLimitError
+	var abuseRateLimitErr *github.AbuseRateLimitError
+	var responseErr *github.ErrorResponse
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &rateLimitErr), errors.As(err, &abuseRateLimitErr):
+		return ErrorClassRateLimited
+	case errors.As(err, &responseErr) && responseErr.Response != nil:
+		switch responseErr.Response.StatusCode {
+		case http.StatusNotFound:
+			return ErrorClassNotFound
+		case http.StatusTooManyRequests:
+			return ErrorClassRateLimited
+		case http.StatusForbidden, http.StatusUnauthorized:
+			return ErrorClassForbidden
+		}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	}
+	return ErrorClassOther
+}
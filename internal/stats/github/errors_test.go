@@ -0,0 +1,34 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "not found", err: &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}, want: ErrorClassNotFound},
+		{name: "forbidden", err: &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusForbidden}}, want: ErrorClassForbidden},
+		{name: "too many requests", err: &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusTooManyRequests}}, want: ErrorClassRateLimited},
+		{name: "rate limited", err: &github.RateLimitError{}, want: ErrorClassRateLimited},
+		{name: "abuse rate limited", err: &github.AbuseRateLimitError{}, want: ErrorClassRateLimited},
+		{name: "timeout", err: fmt.Errorf("get: %w", context.DeadlineExceeded), want: ErrorClassTimeout},
+		{name: "server error", err: &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusBadGateway}}, want: ErrorClassOther},
+		{name: "other", err: assert.AnError, want: ErrorClassOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ErrorClass(tt.err))
+		})
+	}
+}
@@ -3,46 +3,124 @@ package github
 import (
 	"context"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v89/github"
 )
 
 type RepoStats struct {
+	// Name is the repo's name, without its owner. FullName is <owner>/<name>.
 	Name         string
+	FullName     string
 	Stars        int
 	Issues       int
 	PullRequests int
 	Forks        int
 	Archived     bool
+	// Language, Visibility, Fork, Template, License and Topics describe the repo.
+	Language   string
+	Visibility string
+	License    string
+	Topics     []string
+	Fork       bool
+	Template   bool
+	// Size is the size of the repo, in bytes.
+	Size      int64
+	Watchers  int
+	CreatedAt time.Time
+	PushedAt  time.Time
+	UpdatedAt time.Time
+	// DefaultBranch, DeleteBranchOnMerge and SecretScanning hold the repo's settings. Some settings are only
+	// reported to users with admin access to the repo.
+	DefaultBranch       string
+	DeleteBranchOnMerge bool
+	SecretScanning      bool
+	// BranchProtection holds the protection rules of the default branch. Nil if not retrieved.
+	BranchProtection *BranchProtection
+	// PullRequestStats holds the statistics of the pull requests closed in a rolling window. Nil if not retrieved.
+	PullRequestStats *PullRequestStats
+	// Activity holds the repo's commit and contributor activity. Nil if not retrieved.
+	Activity *Activity
+	// Teams lists the configured teams that have access to the repo.
+	Teams []TeamAccess
+	// CustomProperties holds the values of the repo's custom properties, by property name. Nil if not retrieved.
+	CustomProperties map[string]string
 }
 
 type Client struct {
 	Repositories
 	PullRequests
+	Stargazers
+	RateLimits
+	Teams
+	Organizations
+	// Search is a named field, as its Repositories method would clash with the Repositories field.
+	Search Search
+	// StatsAttempts is the maximum number of calls to a statistics endpoint while GitHub is computing the statistics.
+	StatsAttempts int
+	// StatsBackoff is the time to wait between calls to a statistics endpoint.
+	StatsBackoff time.Duration
 }
 
 type Repositories interface {
 	ListByUser(context.Context, string, *github.RepositoryListByUserOptions) ([]*github.Repository, *github.Response, error)
 	Get(context.Context, string, string) (*github.Repository, *github.Response, error)
+	ListCommitActivity(context.Context, string, string) ([]*github.WeeklyCommitActivity, *github.Response, error)
+	ListContributorsStats(context.Context, string, string) ([]*github.ContributorStats, *github.Response, error)
+	ListForks(context.Context, string, string, *github.RepositoryListForksOptions) ([]*github.Repository, *github.Response, error)
+	GetBranchProtection(context.Context, string, string, string) (*github.Protection, *github.Response, error)
+}
+
+type Stargazers interface {
+	ListStargazers(context.Context, string, string, *github.ListOptions) ([]*github.Stargazer, *github.Response, error)
 }
 
 type PullRequests interface {
 	List(context.Context, string, string, *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error)
+	Get(context.Context, string, string, int) (*github.PullRequest, *github.Response, error)
+	ListReviews(context.Context, string, string, int, *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error)
 }
 
-func New(tp http.RoundTripper) (*Client, error) {
-	httpClient := http.Client{Transport: tp, Timeout: 10 * time.Second}
-	client, err := github.NewClient(github.WithHTTPClient(&httpClient))
+// New returns a Client that calls the GitHub API through tp. If baseURL is empty, the Client calls the public GitHub API.
+// API calls aren't limited in time: tp should time out calls that hang, e.g. with a retry.Retrier.
+func New(tp http.RoundTripper, baseURL string) (*Client, error) {
+	httpClient := http.Client{Transport: tp}
+	options := []github.ClientOptionsFunc{github.WithHTTPClient(&httpClient)}
+	if baseURL != "" {
+		options = append(options, github.WithURLs(&baseURL, nil))
+	}
+	client, err := github.NewClient(options...)
 	if err != nil {
 		return nil, err
 	}
 	return &Client{
-		Repositories: client.Repositories,
-		PullRequests: client.PullRequests,
+		Repositories:  client.Repositories,
+		PullRequests:  client.PullRequests,
+		Stargazers:    client.Activity,
+		RateLimits:    client.RateLimit,
+		Teams:         client.Teams,
+		Organizations: client.Organizations,
+		Search:        client.Search,
+		StatsAttempts: defaultStatsAttempts,
+		StatsBackoff:  defaultStatsBackoff,
 	}, nil
 }
 
+// HostName returns the name of the GitHub host of baseURL, e.g. github.com for the public GitHub API. The api. prefix
+// of the API host is removed, so the name matches the host of the repos' web URLs.
+func HostName(baseURL string) string {
+	if baseURL == "" {
+		return "github.com"
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Hostname() == "" {
+		return baseURL
+	}
+	return strings.TrimPrefix(u.Hostname(), "api.")
+}
+
 const recordsPerPage = 100
 
 func (c Client) GetUserRepoNames(ctx context.Context, user string) (repos []string, err error) {
@@ -77,13 +155,28 @@ func (c Client) GetUserReposPage(ctx context.Context, user string, page int) (re
 
 func (c Client) GetRepoStats(ctx context.Context, user string, repo string) (RepoStats, error) {
 	var repoStats RepoStats
-	r, _, err := c.Get(ctx, user, repo)
+	r, _, err := c.Repositories.Get(ctx, user, repo)
 	if err == nil {
 		repoStats.Name = r.GetName()
+		repoStats.FullName = r.GetFullName()
 		repoStats.Stars = r.GetStargazersCount()
 		repoStats.Issues = r.GetOpenIssuesCount()
 		repoStats.Forks = r.GetForksCount()
 		repoStats.Archived = r.GetArchived()
+		repoStats.Language = r.GetLanguage()
+		repoStats.Visibility = r.GetVisibility()
+		repoStats.License = r.GetLicense().GetSPDXID()
+		repoStats.Topics = r.Topics
+		repoStats.Fork = r.GetFork()
+		repoStats.Template = r.GetIsTemplate()
+		repoStats.Size = int64(r.GetSize()) * 1024 // GitHub reports the size in kilobytes
+		repoStats.Watchers = r.GetSubscribersCount()
+		repoStats.CreatedAt = r.GetCreatedAt().Time
+		repoStats.PushedAt = r.GetPushedAt().Time
+		repoStats.UpdatedAt = r.GetUpdatedAt().Time
+		repoStats.DefaultBranch = r.GetDefaultBranch()
+		repoStats.DeleteBranchOnMerge = r.GetDeleteBranchOnMerge()
+		repoStats.SecretScanning = r.GetSecurityAndAnalysis().GetSecretScanning().GetStatus() == "enabled"
 	}
 	return repoStats, err
 }
@@ -5,13 +5,30 @@ import (
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/v89/github"
 	"github.com/stretchr/testify/assert"
 )
 
+func TestHostName(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{baseURL: "", want: "github.com"},
+		{baseURL: "https://api.github.com/", want: "github.com"},
+		{baseURL: "https://github.example.com/api/v3/", want: "github.example.com"},
+		{baseURL: "https://api.octocorp.ghe.com/", want: "octocorp.ghe.com"},
+		{baseURL: "http://127.0.0.1:8080/", want: "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, HostName(tt.baseURL), tt.baseURL)
+	}
+}
+
 func TestClient_GetUserRepoNames(t *testing.T) {
-	c, _ := New(http.DefaultTransport)
+	c, _ := New(http.DefaultTransport, "")
 	c.Repositories = fakeRepositories{
 		repoList: map[int]repoPage{
 			0: {
@@ -32,16 +49,27 @@ func TestClient_GetUserRepoNames(t *testing.T) {
 }
 
 func TestClient_GetRepoStats(t *testing.T) {
-	c, _ := New(http.DefaultTransport)
+	c, _ := New(http.DefaultTransport, "")
 	c.Repositories = fakeRepositories{
 		repos: map[string]*github.Repository{
 			"user/repo": {
-				Owner:           &github.User{Name: new("user")},
-				Name:            new("repo"),
-				ForksCount:      new(1),
-				OpenIssuesCount: new(2),
-				StargazersCount: new(4),
-				Archived:        new(true),
+				Owner:               &github.User{Name: new("user")},
+				Name:                new("repo"),
+				ForksCount:          new(1),
+				OpenIssuesCount:     new(2),
+				StargazersCount:     new(4),
+				Archived:            new(true),
+				Language:            new("Go"),
+				Visibility:          new("public"),
+				License:             &github.License{SPDXID: new("MIT")},
+				Topics:              []string{"prometheus", "github"},
+				IsTemplate:          new(true),
+				Size:                new(10),
+				SubscribersCount:    new(3),
+				CreatedAt:           &github.Timestamp{Time: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+				DefaultBranch:       new("main"),
+				DeleteBranchOnMerge: new(true),
+				SecurityAndAnalysis: &github.SecurityAndAnalysis{SecretScanning: &github.SecretScanning{Status: new("enabled")}},
 			},
 		},
 	}
@@ -50,17 +78,28 @@ func TestClient_GetRepoStats(t *testing.T) {
 	repos, err := c.GetRepoStats(ctx, "user", "repo")
 	assert.NoError(t, err)
 	assert.Equal(t, RepoStats{
-		Name:         "repo",
-		Stars:        4,
-		Issues:       2,
-		PullRequests: 0,
-		Forks:        1,
-		Archived:     true,
+		Name:                "repo",
+		Stars:               4,
+		Issues:              2,
+		PullRequests:        0,
+		Forks:               1,
+		Archived:            true,
+		Language:            "Go",
+		Visibility:          "public",
+		License:             "MIT",
+		Topics:              []string{"prometheus", "github"},
+		Template:            true,
+		Size:                10240,
+		Watchers:            3,
+		CreatedAt:           time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		DefaultBranch:       "main",
+		DeleteBranchOnMerge: true,
+		SecretScanning:      true,
 	}, repos)
 }
 
 func TestClient_GetPullRequestCount(t *testing.T) {
-	c, _ := New(http.DefaultTransport)
+	c, _ := New(http.DefaultTransport, "")
 	p := fakePullRequests{
 		prs: map[int]prPage{
 			0: {
@@ -88,8 +127,13 @@ type repoPage struct {
 	resp *github.Response
 }
 type fakeRepositories struct {
-	repoList map[int]repoPage
-	repos    map[string]*github.Repository
+	repoList         map[int]repoPage
+	repos            map[string]*github.Repository
+	accepted         *int
+	commitActivity   []*github.WeeklyCommitActivity
+	contributorStats []*github.ContributorStats
+	forks            map[int]repoPage
+	protection       map[string]*github.Protection
 }
 
 func (f fakeRepositories) ListByUser(_ context.Context, _ string, options *github.RepositoryListByUserOptions) ([]*github.Repository, *github.Response, error) {
@@ -108,6 +152,48 @@ func (f fakeRepositories) Get(ctx context.Context, s1 string, s2 string) (*githu
 	return repo, &github.Response{}, nil
 }
 
+func (f fakeRepositories) ListCommitActivity(_ context.Context, _ string, _ string) ([]*github.WeeklyCommitActivity, *github.Response, error) {
+	if f.notReady() {
+		return nil, &github.Response{}, &github.AcceptedError{}
+	}
+	return f.commitActivity, &github.Response{}, nil
+}
+
+func (f fakeRepositories) ListContributorsStats(_ context.Context, _ string, _ string) ([]*github.ContributorStats, *github.Response, error) {
+	if f.notReady() {
+		return nil, &github.Response{}, &github.AcceptedError{}
+	}
+	return f.contributorStats, &github.Response{}, nil
+}
+
+func (f fakeRepositories) ListForks(_ context.Context, _ string, _ string, options *github.RepositoryListForksOptions) ([]*github.Repository, *github.Response, error) {
+	page, found := f.forks[options.Page]
+	if !found {
+		return nil, nil, errors.New("page not found")
+	}
+	return page.repo, page.resp, nil
+}
+
+func (f fakeRepositories) GetBranchProtection(_ context.Context, _ string, _ string, branch string) (*github.Protection, *github.Response, error) {
+	if f.protection == nil {
+		return nil, nil, errors.New("not found")
+	}
+	p, found := f.protection[branch]
+	if !found {
+		return nil, nil, github.ErrBranchNotProtected
+	}
+	return p, &github.Response{}, nil
+}
+
+// notReady simulates GitHub computing the statistics: the first *accepted calls return 202 Accepted.
+func (f fakeRepositories) notReady() bool {
+	if f.accepted == nil || *f.accepted == 0 {
+		return false
+	}
+	*f.accepted--
+	return true
+}
+
 var _ PullRequests = &fakePullRequests{}
 
 type prPage struct {
@@ -115,7 +201,9 @@ type prPage struct {
 	resp *github.Response
 }
 type fakePullRequests struct {
-	prs map[int]prPage
+	prs     map[int]prPage
+	pulls   map[int]*github.PullRequest
+	reviews map[int][]*github.PullRequestReview
 }
 
 func (f fakePullRequests) List(_ context.Context, _ string, _ string, options *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
@@ -125,3 +213,15 @@ func (f fakePullRequests) List(_ context.Context, _ string, _ string, options *g
 	}
 	return page.prs, page.resp, nil
 }
+
+func (f fakePullRequests) Get(_ context.Context, _ string, _ string, number int) (*github.PullRequest, *github.Response, error) {
+	pr, found := f.pulls[number]
+	if !found {
+		return nil, nil, errors.New("pr not found")
+	}
+	return pr, &github.Response{}, nil
+}
+
+func (f fakePullRequests) ListReviews(_ context.Context, _ string, _ string, number int, _ *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+	return f.reviews[number], &github.Response{}, nil
+}
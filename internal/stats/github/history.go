@@ -0,0 +1,47 @@
+package github
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+)
+
+// GetStargazerTimes returns the time at which each current stargazer starred the repo.
+// Stars that were later removed are not reported by GitHub.
+func (c Client) GetStargazerTimes(ctx context.Context, user string, repo string) (starredAt []time.Time, err error) {
+	opt := github.ListOptions{PerPage: recordsPerPage}
+	for {
+		var stargazers []*github.Stargazer
+		var resp *github.Response
+		if stargazers, resp, err = c.ListStargazers(ctx, user, repo, &opt); err != nil {
+			return nil, err
+		}
+		for _, stargazer := range stargazers {
+			starredAt = append(starredAt, stargazer.GetStarredAt().Time)
+		}
+		if resp.NextPage == 0 {
+			return starredAt, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// GetForkTimes returns the creation time of each fork of the repo.
+func (c Client) GetForkTimes(ctx context.Context, user string, repo string) (createdAt []time.Time, err error) {
+	opt := github.RepositoryListForksOptions{Sort: "oldest", ListOptions: github.ListOptions{PerPage: recordsPerPage}}
+	for {
+		var forks []*github.Repository
+		var resp *github.Response
+		if forks, resp, err = c.ListForks(ctx, user, repo, &opt); err != nil {
+			return nil, err
+		}
+		for _, fork := range forks {
+			createdAt = append(createdAt, fork.GetCreatedAt().Time)
+		}
+		if resp.NextPage == 0 {
+			return createdAt, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,78 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetStargazerTimes(t *testing.T) {
+	t1 := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, time.February, 1, 12, 0, 0, 0, time.UTC)
+
+	c, _ := New(http.DefaultTransport, "")
+	c.Stargazers = fakeStargazers{
+		0: {
+			stargazers: []*github.Stargazer{{StarredAt: &github.Timestamp{Time: t1}}},
+			resp:       &github.Response{NextPage: 2},
+		},
+		2: {
+			stargazers: []*github.Stargazer{{StarredAt: &github.Timestamp{Time: t2}}},
+			resp:       &github.Response{},
+		},
+	}
+
+	starredAt, err := c.GetStargazerTimes(context.Background(), "user", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{t1, t2}, starredAt)
+
+	c.Stargazers = fakeStargazers{}
+	_, err = c.GetStargazerTimes(context.Background(), "user", "repo")
+	assert.Error(t, err)
+}
+
+func TestClient_GetForkTimes(t *testing.T) {
+	t1 := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, time.February, 1, 12, 0, 0, 0, time.UTC)
+
+	c, _ := New(http.DefaultTransport, "")
+	c.Repositories = fakeRepositories{
+		forks: map[int]repoPage{
+			0: {
+				repo: []*github.Repository{{CreatedAt: &github.Timestamp{Time: t1}}},
+				resp: &github.Response{NextPage: 1},
+			},
+			1: {
+				repo: []*github.Repository{{CreatedAt: &github.Timestamp{Time: t2}}},
+				resp: &github.Response{},
+			},
+		},
+	}
+
+	createdAt, err := c.GetForkTimes(context.Background(), "user", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{t1, t2}, createdAt)
+}
+
+var _ Stargazers = fakeStargazers{}
+
+type stargazerPage struct {
+	stargazers []*github.Stargazer
+	resp       *github.Response
+}
+
+type fakeStargazers map[int]stargazerPage
+
+func (f fakeStargazers) ListStargazers(_ context.Context, _ string, _ string, options *github.ListOptions) ([]*github.Stargazer, *github.Response, error) {
+	page, found := f[options.Page]
+	if !found {
+		return nil, nil, errors.New("page not found")
+	}
+	return page.stargazers, page.resp, nil
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v89/github"
+)
+
+type Organizations interface {
+	ListCustomPropertyValues(context.Context, string, *github.ListCustomPropertyValuesOptions) ([]*github.RepoCustomPropertyValue, *github.Response, error)
+}
+
+// GetCustomProperties returns the values of the custom properties of the org's repos, by full repo name. The values of
+// multi-select properties are sorted and joined with commas. Properties without a value are omitted.
+//
+// Custom properties only exist for orgs: for other accounts, GetCustomProperties returns no properties.
+func (c Client) GetCustomProperties(ctx context.Context, org string) (map[string]map[string]string, error) {
+	opt := github.ListCustomPropertyValuesOptions{ListOptions: github.ListOptions{PerPage: recordsPerPage}}
+	properties := make(map[string]map[string]string)
+	for {
+		repos, resp, err := c.ListCustomPropertyValues(ctx, org, &opt)
+		if err != nil {
+			if ErrorClass(err) == ErrorClassNotFound {
+				return properties, nil
+			}
+			return nil, err
+		}
+		for _, repo := range repos {
+			values := make(map[string]string, len(repo.Properties))
+			for _, property := range repo.Properties {
+				if value := propertyValue(property.Value); value != "" {
+					values[property.PropertyName] = value
+				}
+			}
+			properties[repo.RepositoryFullName] = values
+		}
+		if resp.NextPage == 0 {
+			return properties, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func propertyValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case []string:
+		return strings.Join(slices.Sorted(slices.Values(value)), ",")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,81 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetCustomProperties(t *testing.T) {
+	tests := []struct {
+		name    string
+		orgs    fakeOrganizations
+		wantErr assert.ErrorAssertionFunc
+		want    map[string]map[string]string
+	}{
+		{
+			name: "pages",
+			orgs: fakeOrganizations{pages: [][]*github.RepoCustomPropertyValue{
+				{
+					{RepositoryFullName: "foo/a", Properties: []*github.CustomPropertyValue{
+						{PropertyName: "service-tier", Value: "1"},
+						{PropertyName: "platforms", Value: []string{"linux", "darwin"}},
+						{PropertyName: "cost-center"},
+					}},
+				},
+				{
+					{RepositoryFullName: "foo/b"},
+				},
+			}},
+			wantErr: assert.NoError,
+			want: map[string]map[string]string{
+				"foo/a": {"service-tier": "1", "platforms": "darwin,linux"},
+				"foo/b": {},
+			},
+		},
+		{
+			name:    "not an org",
+			orgs:    fakeOrganizations{err: &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}},
+			wantErr: assert.NoError,
+			want:    map[string]map[string]string{},
+		},
+		{
+			name:    "error",
+			orgs:    fakeOrganizations{err: errors.New("failed")},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := New(http.DefaultTransport, "")
+			c.Organizations = tt.orgs
+			properties, err := c.GetCustomProperties(context.Background(), "foo")
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, properties)
+		})
+	}
+}
+
+var _ Organizations = fakeOrganizations{}
+
+type fakeOrganizations struct {
+	pages [][]*github.RepoCustomPropertyValue
+	err   error
+}
+
+func (f fakeOrganizations) ListCustomPropertyValues(_ context.Context, _ string, opt *github.ListCustomPropertyValuesOptions) ([]*github.RepoCustomPropertyValue, *github.Response, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	page := max(opt.Page, 1)
+	var resp github.Response
+	if page < len(f.pages) {
+		resp.NextPage = page + 1
+	}
+	return f.pages[page-1], &resp, nil
+}
@@ -0,0 +1,98 @@
+package github
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+)
+
+// PullRequest holds the history of a closed pull request.
+type PullRequest struct {
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ClosedAt      time.Time
+	MergedAt      time.Time
+	FirstReviewAt time.Time
+	Number        int
+	// Lines is the number of lines changed (added or deleted) by the pull request.
+	Lines int
+}
+
+// Merged returns true if the pull request was merged.
+func (p PullRequest) Merged() bool {
+	return !p.MergedAt.IsZero()
+}
+
+// PullRequestStats holds the statistics of the pull requests closed in a rolling window.
+type PullRequestStats struct {
+	TimeToFirstReview []time.Duration
+	TimeToMerge       []time.Duration
+	Lines             []int
+	Merged            int
+	Unmerged          int
+}
+
+// GetClosedPullRequests returns the closed pull requests that were updated since the given time, most recently updated
+// first. Since pull requests are listed by update time, only the pull requests that changed since the last call are read.
+// Each returned pull request takes two additional API calls to get its size and reviews.
+func (c Client) GetClosedPullRequests(ctx context.Context, user string, repo string, since time.Time) ([]PullRequest, error) {
+	opt := github.PullRequestListOptions{
+		State:       "closed",
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: recordsPerPage},
+	}
+	var pulls []PullRequest
+	for {
+		prs, resp, err := c.List(ctx, user, repo, &opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			if pr.GetUpdatedAt().Before(since) {
+				return pulls, nil
+			}
+			pull, err := c.getClosedPullRequest(ctx, user, repo, pr)
+			if err != nil {
+				return nil, err
+			}
+			pulls = append(pulls, pull)
+		}
+		if resp.NextPage == 0 {
+			return pulls, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func (c Client) getClosedPullRequest(ctx context.Context, user string, repo string, pr *github.PullRequest) (PullRequest, error) {
+	// listed pull requests don't include the number of lines changed
+	pr, _, err := c.PullRequests.Get(ctx, user, repo, pr.GetNumber())
+	if err != nil {
+		return PullRequest{}, err
+	}
+	pull := PullRequest{
+		Number:    pr.GetNumber(),
+		CreatedAt: pr.GetCreatedAt().Time,
+		UpdatedAt: pr.GetUpdatedAt().Time,
+		ClosedAt:  pr.GetClosedAt().Time,
+		MergedAt:  pr.GetMergedAt().Time,
+		Lines:     pr.GetAdditions() + pr.GetDeletions(),
+	}
+
+	// reviews are listed in chronological order
+	reviews, _, err := c.ListReviews(ctx, user, repo, pr.GetNumber(), &github.ListOptions{PerPage: recordsPerPage})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	for _, review := range reviews {
+		// ignore pending reviews and the author's replies to review comments
+		if review.SubmittedAt == nil || review.GetUser().GetLogin() == pr.GetUser().GetLogin() {
+			continue
+		}
+		pull.FirstReviewAt = review.GetSubmittedAt().Time
+		break
+	}
+	return pull, nil
+}
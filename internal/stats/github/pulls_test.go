@@ -0,0 +1,59 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetClosedPullRequests(t *testing.T) {
+	ts := func(day int) *github.Timestamp {
+		return &github.Timestamp{Time: time.Date(2024, time.January, day, 0, 0, 0, 0, time.UTC)}
+	}
+	author := &github.User{Login: new("author")}
+	reviewer := &github.User{Login: new("reviewer")}
+
+	c, _ := New(http.DefaultTransport, "")
+	c.PullRequests = fakePullRequests{
+		prs: map[int]prPage{
+			0: {
+				prs:  []*github.PullRequest{{Number: new(3), UpdatedAt: ts(10)}},
+				resp: &github.Response{NextPage: 1},
+			},
+			1: {
+				prs: []*github.PullRequest{
+					{Number: new(2), UpdatedAt: ts(8)},
+					{Number: new(1), UpdatedAt: ts(2)},
+				},
+				resp: &github.Response{NextPage: 2},
+			},
+		},
+		pulls: map[int]*github.PullRequest{
+			3: {Number: new(3), User: author, CreatedAt: ts(6), UpdatedAt: ts(10), ClosedAt: ts(9), MergedAt: ts(9), Additions: new(10), Deletions: new(5)},
+			2: {Number: new(2), User: author, CreatedAt: ts(7), UpdatedAt: ts(8), ClosedAt: ts(8), Additions: new(1)},
+		},
+		reviews: map[int][]*github.PullRequestReview{
+			3: {
+				{User: reviewer},
+				{User: author, SubmittedAt: ts(6)},
+				{User: reviewer, SubmittedAt: ts(7)},
+				{User: reviewer, SubmittedAt: ts(8)},
+			},
+		},
+	}
+
+	pulls, err := c.GetClosedPullRequests(context.Background(), "foo", "bar", ts(5).Time)
+	require.NoError(t, err)
+	want := []PullRequest{
+		{Number: 3, CreatedAt: ts(6).Time, UpdatedAt: ts(10).Time, ClosedAt: ts(9).Time, MergedAt: ts(9).Time, FirstReviewAt: ts(7).Time, Lines: 15},
+		{Number: 2, CreatedAt: ts(7).Time, UpdatedAt: ts(8).Time, ClosedAt: ts(8).Time, Lines: 1},
+	}
+	assert.Equal(t, want, pulls)
+	assert.True(t, pulls[0].Merged())
+	assert.False(t, pulls[1].Merged())
+}
@@ -0,0 +1,76 @@
+package github
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+)
+
+type RateLimits interface {
+	Get(context.Context) (*github.RateLimits, *github.Response, error)
+}
+
+// Quota holds the API quota of a resource, as reported by GitHub.
+type Quota struct {
+	Reset     time.Time
+	Resource  string
+	Limit     int
+	Remaining int
+	Used      int
+}
+
+// GetQuotas returns the API quota of the core, search, graphql and code_search resources.
+// Querying the quota does not count against the quota.
+func (c Client) GetQuotas(ctx context.Context) ([]Quota, error) {
+	limits, _, err := c.RateLimits.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resources := []struct {
+		rate *github.Rate
+		name string
+	}{
+		{name: "core", rate: limits.Core},
+		{name: "search", rate: limits.Search},
+		{name: "graphql", rate: limits.GraphQL},
+		{name: "code_search", rate: limits.CodeSearch},
+	}
+	quotas := make([]Quota, 0, len(resources))
+	for _, resource := range resources {
+		if resource.rate == nil {
+			continue
+		}
+		quotas = append(quotas, Quota{
+			Resource:  resource.name,
+			Limit:     resource.rate.Limit,
+			Remaining: resource.rate.Remaining,
+			Used:      resource.rate.Used,
+			Reset:     resource.rate.Reset.Time,
+		})
+	}
+	return quotas, nil
+}
+
+// TotalQuotas adds up the quotas of several tokens, by resource: requests that are spread across the tokens may use their
+// total quota. The reset time of a resource is the earliest reset time of the tokens.
+func TotalQuotas(tokens ...[]Quota) []Quota {
+	var total []Quota
+	for _, quotas := range tokens {
+		for _, quota := range quotas {
+			i := slices.IndexFunc(total, func(q Quota) bool { return q.Resource == quota.Resource })
+			if i < 0 {
+				total = append(total, quota)
+				continue
+			}
+			total[i].Limit += quota.Limit
+			total[i].Remaining += quota.Remaining
+			total[i].Used += quota.Used
+			if quota.Reset.Before(total[i].Reset) {
+				total[i].Reset = quota.Reset
+			}
+		}
+	}
+	return total
+}
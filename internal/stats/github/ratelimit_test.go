@@ -0,0 +1,79 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetQuotas(t *testing.T) {
+	reset := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		limits  fakeRateLimits
+		wantErr assert.ErrorAssertionFunc
+		want    []Quota
+	}{
+		{
+			name: "valid",
+			limits: fakeRateLimits{limits: &github.RateLimits{
+				Core:                &github.Rate{Limit: 5000, Remaining: 4000, Used: 1000, Reset: github.Timestamp{Time: reset}},
+				Search:              &github.Rate{Limit: 30, Remaining: 30, Reset: github.Timestamp{Time: reset}},
+				IntegrationManifest: &github.Rate{Limit: 5000},
+			}},
+			wantErr: assert.NoError,
+			want: []Quota{
+				{Resource: "core", Limit: 5000, Remaining: 4000, Used: 1000, Reset: reset},
+				{Resource: "search", Limit: 30, Remaining: 30, Reset: reset},
+			},
+		},
+		{
+			name:    "error",
+			limits:  fakeRateLimits{err: errors.New("failed")},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := New(http.DefaultTransport, "")
+			c.RateLimits = tt.limits
+			quotas, err := c.GetQuotas(context.Background())
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, quotas)
+		})
+	}
+}
+
+var _ RateLimits = fakeRateLimits{}
+
+type fakeRateLimits struct {
+	limits *github.RateLimits
+	err    error
+}
+
+func (f fakeRateLimits) Get(context.Context) (*github.RateLimits, *github.Response, error) {
+	return f.limits, nil, f.err
+}
+
+func TestTotalQuotas(t *testing.T) {
+	reset := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	got := TotalQuotas(
+		[]Quota{
+			{Resource: "core", Limit: 5000, Remaining: 4000, Used: 1000, Reset: reset.Add(time.Minute)},
+			{Resource: "search", Limit: 30, Remaining: 30, Reset: reset},
+		},
+		[]Quota{
+			{Resource: "core", Limit: 1000, Remaining: 900, Used: 100, Reset: reset},
+		},
+	)
+	assert.Equal(t, []Quota{
+		{Resource: "core", Limit: 6000, Remaining: 4900, Used: 1100, Reset: reset},
+		{Resource: "search", Limit: 30, Remaining: 30, Reset: reset},
+	}, got)
+}
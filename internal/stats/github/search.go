@@ -0,0 +1,82 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+)
+
+type Search interface {
+	Repositories(context.Context, string, *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error)
+}
+
+const (
+	// searchAttempts is the maximum number of calls to the search API for one page of results, when the search rate
+	// limit is hit.
+	searchAttempts = 3
+	// maxSearchWait is the longest time to wait for the search rate limit to reset. The search rate limit window is
+	// one minute, so longer waits indicate a different problem.
+	maxSearchWait = 2 * time.Minute
+)
+
+// GetTopicRepoNames returns the names of the org's unarchived repos with the topic, using the search API.
+//
+// The search API has its own rate limit, which is much lower than the core rate limit. If the rate limit is hit,
+// GetTopicRepoNames waits for it to reset and tries again.
+func (c Client) GetTopicRepoNames(ctx context.Context, org string, topic string) ([]string, error) {
+	query := fmt.Sprintf("org:%s topic:%s archived:false", org, topic)
+	opt := github.SearchOptions{ListOptions: github.ListOptions{PerPage: recordsPerPage}}
+	var repos []string
+	for {
+		result, resp, err := c.searchRepositories(ctx, query, &opt)
+		if err != nil {
+			return nil, err
+		}
+		// GitHub returns partial results if the search timed out
+		if result.GetIncompleteResults() {
+			return nil, fmt.Errorf("search %q: incomplete results", query)
+		}
+		for _, repo := range result.Repositories {
+			repos = append(repos, repo.GetFullName())
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// searchRepositories calls the search API, waiting for the search rate limit to reset when it is hit.
+func (c Client) searchRepositories(ctx context.Context, query string, opt *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error) {
+	for attempt := 1; ; attempt++ {
+		result, resp, err := c.Search.Repositories(ctx, query, opt)
+		if err == nil || attempt >= searchAttempts {
+			return result, resp, err
+		}
+		wait, ok := searchWait(err, time.Now())
+		if !ok || wait > maxSearchWait {
+			return result, resp, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// searchWait returns how long to wait before calling the search API again. It returns false if err is not a rate limit error.
+func searchWait(err error, now time.Time) (time.Duration, bool) {
+	var rateLimitErr *github.RateLimitError
+	var abuseRateLimitErr *github.AbuseRateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		return max(0, rateLimitErr.Rate.Reset.Sub(now)), true
+	case errors.As(err, &abuseRateLimitErr) && abuseRateLimitErr.RetryAfter != nil:
+		return *abuseRateLimitErr.RetryAfter, true
+	}
+	return 0, false
+}
@@ -0,0 +1,127 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetTopicRepoNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		search  *fakeSearch
+		wantErr assert.ErrorAssertionFunc
+		want    []string
+	}{
+		{
+			name:    "pages",
+			search:  &fakeSearch{pages: [][]string{{"foo/a", "foo/b"}, {"foo/c"}}},
+			wantErr: assert.NoError,
+			want:    []string{"foo/a", "foo/b", "foo/c"},
+		},
+		{
+			name:    "no repos",
+			search:  &fakeSearch{pages: [][]string{nil}},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "rate limited",
+			search: &fakeSearch{
+				pages: [][]string{{"foo/a"}},
+				errs:  []error{&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Now().Add(10 * time.Millisecond)}}}},
+			},
+			wantErr: assert.NoError,
+			want:    []string{"foo/a"},
+		},
+		{
+			name: "secondary rate limit",
+			search: &fakeSearch{
+				pages: [][]string{{"foo/a"}},
+				errs:  []error{&github.AbuseRateLimitError{RetryAfter: new(10 * time.Millisecond)}},
+			},
+			wantErr: assert.NoError,
+			want:    []string{"foo/a"},
+		},
+		{
+			name: "rate limit resets too late",
+			search: &fakeSearch{
+				pages: [][]string{{"foo/a"}},
+				errs:  []error{&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Now().Add(time.Hour)}}}},
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name: "rate limit attempts exhausted",
+			search: &fakeSearch{
+				pages: [][]string{{"foo/a"}},
+				errs:  []error{&github.RateLimitError{}, &github.RateLimitError{}, &github.RateLimitError{}},
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "error",
+			search:  &fakeSearch{errs: []error{errors.New("failed")}},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "incomplete results",
+			search:  &fakeSearch{pages: [][]string{{"foo/a"}}, incomplete: true},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := New(http.DefaultTransport, "")
+			c.Search = tt.search
+			repos, err := c.GetTopicRepoNames(context.Background(), "foo", "team-a")
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, repos)
+		})
+	}
+}
+
+func TestClient_GetTopicRepoNames_Query(t *testing.T) {
+	search := fakeSearch{pages: [][]string{nil}}
+	c, _ := New(http.DefaultTransport, "")
+	c.Search = &search
+	_, err := c.GetTopicRepoNames(context.Background(), "foo", "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, "org:foo topic:team-a archived:false", search.query)
+}
+
+var _ Search = &fakeSearch{}
+
+// fakeSearch returns the pages of repos. The first calls fail with errs.
+type fakeSearch struct {
+	query      string
+	pages      [][]string
+	errs       []error
+	incomplete bool
+}
+
+func (f *fakeSearch) Repositories(_ context.Context, query string, opt *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error) {
+	f.query = query
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, nil, err
+	}
+	page := max(opt.Page, 1)
+	var result github.RepositoriesSearchResult
+	result.IncompleteResults = new(f.incomplete)
+	for _, name := range f.pages[page-1] {
+		result.Repositories = append(result.Repositories, &github.Repository{FullName: new(name)})
+	}
+	var resp github.Response
+	if page < len(f.pages) {
+		resp.NextPage = page + 1
+	}
+	return &result, &resp, nil
+}
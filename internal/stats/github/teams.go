@@ -0,0 +1,74 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v89/github"
+)
+
+type Teams interface {
+	ListTeamReposBySlug(context.Context, string, string, *github.ListOptions) ([]*github.Repository, *github.Response, error)
+}
+
+// TeamAccess is the access of a team to a repo. Team slugs are unique within their Org only.
+type TeamAccess struct {
+	Org  string
+	Team string
+	// Permission is the team's role for the repo: admin, maintain, write, triage or read. Custom roles are reported by name.
+	Permission string
+}
+
+// TeamRepo is a repo that a team has access to.
+type TeamRepo struct {
+	// Name is the full name of the repo, i.e. <owner>/<repo>.
+	Name string
+	TeamAccess
+}
+
+// GetTeamRepos returns the repos that the org's team has access to, with the team's permission for each repo.
+// Listing a team's repos requires a token with read access to the org's teams.
+func (c Client) GetTeamRepos(ctx context.Context, org string, team string) ([]TeamRepo, error) {
+	opt := github.ListOptions{PerPage: recordsPerPage}
+	var repos []TeamRepo
+	for {
+		teamRepos, resp, err := c.ListTeamReposBySlug(ctx, org, team, &opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range teamRepos {
+			repos = append(repos, TeamRepo{
+				Name:       repo.GetFullName(),
+				TeamAccess: TeamAccess{Org: org, Team: team, Permission: permission(repo)},
+			})
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// permission returns the team's role for the repo. Older GitHub Enterprise Server versions don't report the role,
+// so the role is derived from the highest permission.
+func permission(repo *github.Repository) string {
+	if role := repo.GetRoleName(); role != "" {
+		return role
+	}
+	permissions := repo.GetPermissions()
+	roles := []struct {
+		granted bool
+		role    string
+	}{
+		{granted: permissions.GetAdmin(), role: "admin"},
+		{granted: permissions.GetMaintain(), role: "maintain"},
+		{granted: permissions.GetPush(), role: "write"},
+		{granted: permissions.GetTriage(), role: "triage"},
+		{granted: permissions.GetPull(), role: "read"},
+	}
+	for _, role := range roles {
+		if role.granted {
+			return role.role
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetTeamRepos(t *testing.T) {
+	tests := []struct {
+		name    string
+		teams   fakeTeams
+		wantErr assert.ErrorAssertionFunc
+		want    []TeamRepo
+	}{
+		{
+			name: "pages",
+			teams: fakeTeams{pages: [][]*github.Repository{
+				{
+					{FullName: new("foo/a"), RoleName: new("admin")},
+					{FullName: new("foo/b"), RoleName: new("deployer")},
+				},
+				{
+					{FullName: new("foo/c"), Permissions: &github.RepositoryPermissions{Pull: new(true), Triage: new(true), Push: new(true)}},
+					{FullName: new("foo/d"), Permissions: &github.RepositoryPermissions{Pull: new(true)}},
+					{FullName: new("foo/e")},
+				},
+			}},
+			wantErr: assert.NoError,
+			want: []TeamRepo{
+				{Name: "foo/a", TeamAccess: TeamAccess{Org: "foo", Team: "payments", Permission: "admin"}},
+				{Name: "foo/b", TeamAccess: TeamAccess{Org: "foo", Team: "payments", Permission: "deployer"}},
+				{Name: "foo/c", TeamAccess: TeamAccess{Org: "foo", Team: "payments", Permission: "write"}},
+				{Name: "foo/d", TeamAccess: TeamAccess{Org: "foo", Team: "payments", Permission: "read"}},
+				{Name: "foo/e", TeamAccess: TeamAccess{Org: "foo", Team: "payments"}},
+			},
+		},
+		{
+			name:    "error",
+			teams:   fakeTeams{err: errors.New("failed")},
+			wantErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := New(http.DefaultTransport, "")
+			c.Teams = tt.teams
+			repos, err := c.GetTeamRepos(context.Background(), "foo", "payments")
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, repos)
+		})
+	}
+}
+
+var _ Teams = fakeTeams{}
+
+type fakeTeams struct {
+	pages [][]*github.Repository
+	err   error
+}
+
+func (f fakeTeams) ListTeamReposBySlug(_ context.Context, _ string, _ string, opt *github.ListOptions) ([]*github.Repository, *github.Response, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	page := max(opt.Page, 1)
+	var resp github.Response
+	if page < len(f.pages) {
+		resp.NextPage = page + 1
+	}
+	return f.pages[page-1], &resp, nil
+}
@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// PropertyFilter selects the repos that have one of the Values for the custom property Property. For multi-select
+// properties, the repo is selected if any of its values is listed.
+type PropertyFilter struct {
+	Property string
+	Values   []string
+}
+
+func (f PropertyFilter) matches(properties map[string]string) bool {
+	value, ok := properties[f.Property]
+	if !ok {
+		return false
+	}
+	for _, v := range strings.Split(value, ",") {
+		if slices.Contains(f.Values, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// customProperties reads the custom properties of the repos. GitHub reports the custom properties of all repos of an
+// org in one call, so each org is only read once.
+type customProperties struct {
+	client GitHubClient
+	// orgs holds the properties of each org's repos. Both orgs and repos are keyed by their lowercase name.
+	orgs map[string]map[string]map[string]string
+	lock sync.Mutex
+}
+
+func newCustomProperties(client GitHubClient) *customProperties {
+	return &customProperties{client: client, orgs: make(map[string]map[string]map[string]string)}
+}
+
+// get returns the custom properties of the repo.
+func (p *customProperties) get(ctx context.Context, repo string) (map[string]string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	repo = strings.ToLower(repo)
+	org, _, _ := strings.Cut(repo, "/")
+	repos, ok := p.orgs[org]
+	if !ok {
+		properties, err := p.client.GetCustomProperties(ctx, org)
+		if err != nil {
+			return nil, fmt.Errorf("get custom properties for %s: %w", org, err)
+		}
+		repos = make(map[string]map[string]string, len(properties))
+		for name, values := range properties {
+			repos[strings.ToLower(name)] = values
+		}
+		p.orgs[org] = repos
+	}
+	return repos[repo], nil
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+)
+
+// PullRequestTracker keeps the pull requests of each repo that were closed in a rolling window. Each refresh only reads
+// the pull requests that changed since the previous refresh.
+type PullRequestTracker struct {
+	repos  map[string]*trackedRepo
+	Window time.Duration
+	lock   sync.Mutex
+}
+
+type trackedRepo struct {
+	since time.Time
+	pulls map[int]github.PullRequest
+}
+
+// update reads the pull requests that changed since the last update and returns the statistics of the pull requests
+// closed in the window.
+func (t *PullRequestTracker) update(ctx context.Context, c GitHubClient, user string, repo string, now time.Time) (github.PullRequestStats, error) {
+	tracked := t.repo(user + "/" + repo)
+	start := now.Add(-t.Window)
+
+	since := tracked.since
+	if since.Before(start) {
+		since = start
+	}
+	pulls, err := c.GetClosedPullRequests(ctx, user, repo, since)
+	if err != nil {
+		return github.PullRequestStats{}, err
+	}
+	for _, pull := range pulls {
+		tracked.pulls[pull.Number] = pull
+	}
+	tracked.since = now
+
+	var stats github.PullRequestStats
+	for number, pull := range tracked.pulls {
+		if pull.ClosedAt.Before(start) {
+			delete(tracked.pulls, number)
+			continue
+		}
+		if !pull.FirstReviewAt.IsZero() {
+			stats.TimeToFirstReview = append(stats.TimeToFirstReview, pull.FirstReviewAt.Sub(pull.CreatedAt))
+		}
+		stats.Lines = append(stats.Lines, pull.Lines)
+		if pull.Merged() {
+			stats.Merged++
+			stats.TimeToMerge = append(stats.TimeToMerge, pull.MergedAt.Sub(pull.CreatedAt))
+		} else {
+			stats.Unmerged++
+		}
+	}
+	return stats, nil
+}
+
+// repo returns the tracked pull requests of a repo. Each repo is only updated by one refresh at a time, so the caller
+// doesn't need to hold the lock while updating the repo.
+func (t *PullRequestTracker) repo(name string) *trackedRepo {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.repos == nil {
+		t.repos = make(map[string]*trackedRepo)
+	}
+	tracked, ok := t.repos[name]
+	if !ok {
+		tracked = &trackedRepo{pulls: make(map[int]github.PullRequest)}
+		t.repos[name] = tracked
+	}
+	return tracked
+}
@@ -0,0 +1,44 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullRequestTracker_update(t *testing.T) {
+	now := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	day := func(day int) time.Time { return time.Date(2024, time.January, day, 0, 0, 0, 0, time.UTC) }
+
+	c := fakeGitHubClient{closedPulls: []github.PullRequest{
+		{Number: 1, CreatedAt: day(1), UpdatedAt: day(2), ClosedAt: day(2), MergedAt: day(2), Lines: 100},
+		{Number: 2, CreatedAt: day(20), UpdatedAt: day(22), ClosedAt: day(22), MergedAt: day(22), FirstReviewAt: day(21), Lines: 10},
+		{Number: 3, CreatedAt: day(24), UpdatedAt: day(25), ClosedAt: day(25), Lines: 5},
+	}}
+	tracker := PullRequestTracker{Window: 14 * 24 * time.Hour}
+
+	stats, err := tracker.update(context.Background(), c, "foo", "bar", now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Merged)
+	assert.Equal(t, 1, stats.Unmerged)
+	assert.Equal(t, []time.Duration{24 * time.Hour}, stats.TimeToFirstReview)
+	assert.Equal(t, []time.Duration{48 * time.Hour}, stats.TimeToMerge)
+	assert.ElementsMatch(t, []int{10, 5}, stats.Lines)
+
+	// the next update only reads pull requests that changed since the previous update
+	c.closedPulls = append(c.closedPulls, github.PullRequest{Number: 4, CreatedAt: day(30), UpdatedAt: now.Add(time.Hour), ClosedAt: now.Add(time.Hour), MergedAt: now.Add(time.Hour)})
+	stats, err = tracker.update(context.Background(), c, "foo", "bar", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Merged)
+	assert.Equal(t, 1, stats.Unmerged)
+
+	// pull requests that were closed before the window are dropped
+	stats, err = tracker.update(context.Background(), c, "foo", "bar", now.Add(10*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Merged)
+	assert.Equal(t, 0, stats.Unmerged)
+}
@@ -12,37 +12,103 @@ import (
 
 	"codeberg.org/clambin/go-common/set"
 	"github.com/clambin/github-exporter/internal/stats/github"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/clambin/github-exporter/internal/stats")
+
 type Client struct {
 	GitHubClient
 	Logger *slog.Logger
+	// MaxConcurrentRepos is the maximum number of repos retrieved in parallel. Defaults to 10.
+	MaxConcurrentRepos int
+	// SkipPullRequestCount skips the count of open pull requests. The repo's Issues then include its open pull requests.
+	SkipPullRequestCount bool
+	// IncludeActivity retrieves the commit and contributor activity of each repo.
+	IncludeActivity bool
+	// IncludeCompliance retrieves the protection rules of each repo's default branch.
+	IncludeCompliance bool
+	// PullRequestTracker computes the statistics of recently closed pull requests. Nil to skip these statistics.
+	PullRequestTracker *PullRequestTracker
+	// Topics discovers the repos with a topic in an org, in addition to the users and repos passed to GetRepoStats.
+	Topics []Topic
+	// TeamTracker discovers the repos that the teams have access to, and reports the teams of each repo. Nil to skip teams.
+	TeamTracker *TeamTracker
+	// Properties lists the custom properties to report in each repo's CustomProperties.
+	Properties []string
+	// PropertyFilters only selects the repos that match all filters.
+	PropertyFilters []PropertyFilter
+}
+
+// Topic selects the unarchived repos of Org that have the topic Topic.
+type Topic struct {
+	Org   string
+	Topic string
 }
 
 type GitHubClient interface {
 	GetUserRepoNames(context.Context, string) ([]string, error)
+	GetTopicRepoNames(context.Context, string, string) ([]string, error)
+	GetTeamRepos(context.Context, string, string) ([]github.TeamRepo, error)
+	GetCustomProperties(context.Context, string) (map[string]map[string]string, error)
 	GetRepoStats(context.Context, string, string) (github.RepoStats, error)
 	GetPullRequestCount(context.Context, string, string) (int, error)
+	GetActivity(context.Context, string, string) (github.Activity, error)
+	GetProtection(context.Context, string, string, string) (github.BranchProtection, error)
+	GetClosedPullRequests(context.Context, string, string, time.Time) ([]github.PullRequest, error)
+}
+
+// RepoError is returned by GetRepoStats for each repo that could not be retrieved.
+type RepoError struct {
+	Err  error
+	Repo string
+}
+
+func (e *RepoError) Error() string {
+	return e.Repo + ": " + e.Err.Error()
+}
+
+func (e *RepoError) Unwrap() error {
+	return e.Err
 }
 
+// GetRepoStats returns the statistics of all repos of the users and all repos. If any repos could not be retrieved,
+// the stats of the remaining repos are returned, along with a RepoError for each failed repo.
 func (c Client) GetRepoStats(ctx context.Context, users []string, repos []string) ([]github.RepoStats, error) {
-	var wg sync.WaitGroup
 	type result struct {
 		stats github.RepoStats
 		err   error
 	}
+	names := make(chan string)
 	ch := make(chan result)
+	properties := newCustomProperties(c.GitHubClient)
 
-	var count int
-	for repoName, err := range c.uniqueRepoNames(ctx, users, repos) {
-		if err != nil {
-			return nil, err
+	var namesErr error
+	go func() {
+		defer close(names)
+		for repoName, err := range c.uniqueRepoNames(ctx, users, repos, properties) {
+			if err != nil {
+				namesErr = err
+				return
+			}
+			c.Logger.Debug("repo found", "repo", repoName)
+			names <- repoName
 		}
-		c.Logger.Debug("repo found", "repo", repoName)
-		count++
+	}()
+
+	var wg sync.WaitGroup
+	for range c.workers() {
 		wg.Go(func() {
-			stats, err := c.getStats(ctx, repoName)
-			ch <- result{stats: stats, err: err}
+			for repoName := range names {
+				stats, err := c.getStats(ctx, repoName, properties)
+				if err != nil {
+					err = &RepoError{Repo: repoName, Err: err}
+				}
+				ch <- result{stats: stats, err: err}
+			}
 		})
 	}
 
@@ -51,41 +117,142 @@ func (c Client) GetRepoStats(ctx context.Context, users []string, repos []string
 		close(ch)
 	}()
 
-	errs := make([]error, 0, count)
-	stats := make([]github.RepoStats, 0, count)
+	var errs []error
+	var stats []github.RepoStats
+	var unprotected int
 	for r := range ch {
 		if r.err == nil {
 			stats = append(stats, r.stats)
+			if c.IncludeCompliance && r.stats.BranchProtection == nil {
+				unprotected++
+			}
 		}
 		errs = append(errs, r.err)
 	}
+	if unprotected > 0 {
+		c.Logger.Warn("branch protection unavailable. compliance requires a token with admin access", "repos", unprotected)
+	}
+	// all workers have stopped, so reading namesErr is safe
+	if namesErr != nil {
+		return nil, namesErr
+	}
 	return stats, errors.Join(errs...)
 }
 
-func (c Client) uniqueRepoNames(ctx context.Context, users []string, repos []string) iter.Seq2[string, error] {
+const defaultMaxConcurrentRepos = 10
+
+func (c Client) workers() int {
+	if c.MaxConcurrentRepos > 0 {
+		return c.MaxConcurrentRepos
+	}
+	return defaultMaxConcurrentRepos
+}
+
+// RepoNames returns the names of all repos of the users, all repos and the repos of the client's Topics and teams,
+// without duplicates. Only the repos that match the PropertyFilters are returned.
+func (c Client) RepoNames(ctx context.Context, users []string, repos []string) ([]string, error) {
+	var names []string
+	for repoName, err := range c.uniqueRepoNames(ctx, users, repos, newCustomProperties(c.GitHubClient)) {
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, repoName)
+	}
+	return names, nil
+}
+
+func (c Client) uniqueRepoNames(ctx context.Context, users []string, repos []string, properties *customProperties) iter.Seq2[string, error] {
+	if len(c.PropertyFilters) == 0 {
+		return c.allRepoNames(ctx, users, repos)
+	}
 	return func(yield func(string, error) bool) {
+		for repoName, err := range c.allRepoNames(ctx, users, repos) {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			repoProperties, err := properties.get(ctx, repoName)
+			if err != nil {
+				yield("", err)
+				return
+			}
+			if c.selected(repoProperties) && !yield(repoName, nil) {
+				return
+			}
+		}
+	}
+}
+
+// selected returns true if the custom properties match all PropertyFilters.
+func (c Client) selected(properties map[string]string) bool {
+	for _, filter := range c.PropertyFilters {
+		if !filter.matches(properties) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Client) allRepoNames(ctx context.Context, users []string, repos []string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		// update the teams first, so the teams of all repos are known when their stats are retrieved
+		var teamRepos []string
+		if c.TeamTracker != nil {
+			var err error
+			if teamRepos, err = c.TeamTracker.update(ctx, c.GitHubClient, time.Now()); err != nil {
+				c.Logger.Warn("failed to update team repos. using previous repos", "err", err)
+			}
+		}
 		uniqueRepoNames := set.New(repos...)
+		for _, repo := range uniqueRepoNames.ListOrdered() {
+			if !yield(repo, nil) {
+				return
+			}
+		}
+		yieldNew := func(repos []string) bool {
+			for _, repo := range repos {
+				if !uniqueRepoNames.Contains(repo) {
+					if !yield(repo, nil) {
+						return false
+					}
+					uniqueRepoNames.Add(repo)
+				}
+			}
+			return true
+		}
 		for _, user := range users {
 			userRepos, err := c.GetUserRepoNames(ctx, user)
 			if err != nil {
 				yield("", fmt.Errorf("get repos for user %s: %w", user, err))
 				return
 			}
-			for _, userRepo := range userRepos {
-				if !uniqueRepoNames.Contains(userRepo) {
-					if !yield(userRepo, nil) {
-						return
-					}
-					uniqueRepoNames.Add(userRepo)
-				}
+			if !yieldNew(userRepos) {
+				return
 			}
 		}
+		for _, topic := range c.Topics {
+			topicRepos, err := c.GetTopicRepoNames(ctx, topic.Org, topic.Topic)
+			if err != nil {
+				yield("", fmt.Errorf("get repos for topic %s in org %s: %w", topic.Topic, topic.Org, err))
+				return
+			}
+			if !yieldNew(topicRepos) {
+				return
+			}
+		}
+		yieldNew(teamRepos)
 	}
 }
 
-func (c Client) getStats(ctx context.Context, repo string) (github.RepoStats, error) {
+func (c Client) getStats(ctx context.Context, repo string, properties *customProperties) (_ github.RepoStats, err error) {
+	ctx, span := tracer.Start(ctx, "repo", trace.WithAttributes(attribute.String("vcs.repository.name", repo)))
 	start := time.Now()
 	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to get repo stats")
+		}
+		span.End()
 		c.Logger.Debug("got repo stats", "repo", repo, "duration", time.Since(start))
 	}()
 
@@ -98,11 +265,59 @@ func (c Client) getStats(ctx context.Context, repo string) (github.RepoStats, er
 	if err != nil {
 		return repoStats, err
 	}
-	repoStats.PullRequests, err = c.GetPullRequestCount(ctx, user, repo)
-	if err != nil {
-		return repoStats, err
+	if c.TeamTracker != nil {
+		repoStats.Teams = c.TeamTracker.teams(user + "/" + repo)
+	}
+	if len(c.Properties) > 0 {
+		repoProperties, err := properties.get(ctx, user+"/"+repo)
+		if err != nil {
+			return repoStats, err
+		}
+		repoStats.CustomProperties = make(map[string]string, len(c.Properties))
+		for _, property := range c.Properties {
+			repoStats.CustomProperties[property] = repoProperties[property]
+		}
+	}
+	if !c.SkipPullRequestCount {
+		repoStats.PullRequests, err = c.GetPullRequestCount(ctx, user, repo)
+		if err != nil {
+			return repoStats, err
+		}
+		repoStats.Issues -= repoStats.PullRequests
+	}
+
+	if c.IncludeCompliance {
+		protection, err := c.GetProtection(ctx, user, repo, repoStats.DefaultBranch)
+		switch {
+		case err == nil:
+			repoStats.BranchProtection = &protection
+		case errors.Is(err, github.ErrProtectionUnavailable):
+			// GetRepoStats logs this once for all repos
+			c.Logger.Debug("branch protection unavailable", "repo", user+"/"+repo)
+		default:
+			return repoStats, fmt.Errorf("branch protection: %w", err)
+		}
+	}
+
+	if c.PullRequestTracker != nil {
+		pullRequestStats, err := c.PullRequestTracker.update(ctx, c.GitHubClient, user, repo, time.Now())
+		if err != nil {
+			return repoStats, fmt.Errorf("closed pull requests: %w", err)
+		}
+		repoStats.PullRequestStats = &pullRequestStats
+	}
+
+	if c.IncludeActivity {
+		activity, err := c.GetActivity(ctx, user, repo)
+		switch {
+		case err == nil:
+			repoStats.Activity = &activity
+		case errors.Is(err, github.ErrStatsNotReady):
+			c.Logger.Debug("repo activity not ready", "repo", user+"/"+repo)
+		default:
+			return repoStats, fmt.Errorf("activity: %w", err)
+		}
 	}
-	repoStats.Issues -= repoStats.PullRequests
 	return repoStats, nil
 }
 
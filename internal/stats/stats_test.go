@@ -3,10 +3,15 @@ package stats
 import (
 	"context"
 	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/clambin/github-exporter/internal/stats/github"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestClient_GetRepoStats(t *testing.T) {
@@ -52,14 +57,128 @@ func TestClient_GetRepoStats(t *testing.T) {
 	}
 }
 
+func TestClient_RepoNames(t *testing.T) {
+	c := Client{GitHubClient: fakeGitHubClient{userRepoNames: []string{"foo/bar", "foo/snafu"}}, Logger: slog.Default()}
+	names, err := c.RepoNames(context.Background(), []string{"foo"}, []string{"bar/foo", "foo/bar"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar/foo", "foo/bar", "foo/snafu"}, names)
+
+	c = Client{GitHubClient: fakeGitHubClient{err: assert.AnError}, Logger: slog.Default()}
+	_, err = c.RepoNames(context.Background(), []string{"foo"}, []string{"bar/foo"})
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestClient_RepoNames_Topics(t *testing.T) {
+	c := Client{
+		GitHubClient: fakeGitHubClient{
+			userRepoNames: []string{"foo/bar"},
+			topicRepoNames: map[string][]string{
+				"foo/team-a": {"foo/bar", "foo/snafu"},
+				"bar/team-a": {"bar/foo"},
+			},
+		},
+		Logger: slog.Default(),
+		Topics: []Topic{{Org: "foo", Topic: "team-a"}, {Org: "bar", Topic: "team-a"}, {Org: "bar", Topic: "team-b"}},
+	}
+	names, err := c.RepoNames(context.Background(), []string{"foo"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/bar", "foo/snafu", "bar/foo"}, names)
+
+	c.GitHubClient = fakeGitHubClient{err: assert.AnError}
+	_, err = c.RepoNames(context.Background(), nil, nil)
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestClient_GetRepoStats_Teams(t *testing.T) {
+	c := Client{
+		GitHubClient: fakeGitHubClient{
+			repoStats: github.RepoStats{Name: "bar"},
+			teamRepos: map[string][]github.TeamRepo{
+				"foo/payments": {{Name: "foo/bar", TeamAccess: github.TeamAccess{Org: "foo", Team: "payments", Permission: "admin"}}},
+			},
+		},
+		Logger:      slog.Default(),
+		TeamTracker: &TeamTracker{Teams: []Team{{Org: "foo", Team: "payments"}}, Interval: time.Hour},
+	}
+	stats, err := c.GetRepoStats(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []github.RepoStats{{Name: "bar", Teams: []github.TeamAccess{{Org: "foo", Team: "payments", Permission: "admin"}}}}, stats)
+
+	// if the teams can't be read, the other repos are still listed
+	c.GitHubClient = fakeGitHubClient{err: assert.AnError}
+	c.TeamTracker = &TeamTracker{Teams: []Team{{Org: "foo", Team: "payments"}}, Interval: time.Hour}
+	stats, err = c.GetRepoStats(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+}
+
+func TestClient_GetRepoStats_Properties(t *testing.T) {
+	c := Client{
+		GitHubClient: &propertiesGitHubClient{fakeGitHubClient: fakeGitHubClient{
+			userRepoNames: []string{"foo/a", "foo/b", "foo/c"},
+			properties: map[string]map[string]string{
+				"foo/a": {"service-tier": "1", "cost-center": "payments"},
+				"foo/b": {"service-tier": "2"},
+				"foo/c": {"service-tier": "3"},
+			},
+		}},
+		Logger:          slog.Default(),
+		Properties:      []string{"service-tier", "cost-center"},
+		PropertyFilters: []PropertyFilter{{Property: "service-tier", Values: []string{"1", "2"}}},
+	}
+	stats, err := c.GetRepoStats(context.Background(), []string{"foo"}, []string{"bar/foo"})
+	require.NoError(t, err)
+	want := []github.RepoStats{
+		{Name: "foo/a", CustomProperties: map[string]string{"service-tier": "1", "cost-center": "payments"}},
+		{Name: "foo/b", CustomProperties: map[string]string{"service-tier": "2", "cost-center": ""}},
+	}
+	assert.ElementsMatch(t, want, stats)
+	// each org's properties are read once
+	assert.Equal(t, map[string]int{"foo": 1, "bar": 1}, c.GitHubClient.(*propertiesGitHubClient).calls)
+
+	names, err := c.RepoNames(context.Background(), []string{"foo"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/a", "foo/b"}, names)
+}
+
+func TestPropertyFilter_matches(t *testing.T) {
+	filter := PropertyFilter{Property: "platforms", Values: []string{"linux"}}
+	assert.True(t, filter.matches(map[string]string{"platforms": "linux"}))
+	assert.True(t, filter.matches(map[string]string{"platforms": "darwin,linux"}))
+	assert.False(t, filter.matches(map[string]string{"platforms": "darwin"}))
+	assert.False(t, filter.matches(map[string]string{}))
+}
+
+func TestClient_GetRepoStats_RepoError(t *testing.T) {
+	c := Client{GitHubClient: fakeGitHubClient{err: assert.AnError}, Logger: slog.Default()}
+	_, err := c.GetRepoStats(context.Background(), nil, []string{"foo/bar"})
+	var repoErr *RepoError
+	require.ErrorAs(t, err, &repoErr)
+	assert.Equal(t, "foo/bar", repoErr.Repo)
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestClient_GetRepoStats_MaxConcurrentRepos(t *testing.T) {
+	f := &concurrencyGitHubClient{}
+	c := Client{GitHubClient: f, Logger: slog.Default(), MaxConcurrentRepos: 2}
+	repos := []string{"foo/a", "foo/b", "foo/c", "foo/d", "foo/e"}
+	stats, err := c.GetRepoStats(context.Background(), nil, repos)
+	require.NoError(t, err)
+	assert.Len(t, stats, len(repos))
+	assert.Equal(t, int32(2), f.max.Load())
+}
+
 func TestClient_getStats(t *testing.T) {
 	ctx := context.Background()
 	tests := []struct {
-		name     string
-		ghClient GitHubClient
-		repo     string
-		wantErr  assert.ErrorAssertionFunc
-		want     github.RepoStats
+		name       string
+		ghClient   GitHubClient
+		repo       string
+		activity   bool
+		compliance bool
+		skipPulls  bool
+		wantErr    assert.ErrorAssertionFunc
+		want       github.RepoStats
 	}{
 		{
 			name: "success",
@@ -71,6 +190,74 @@ func TestClient_getStats(t *testing.T) {
 			wantErr: assert.NoError,
 			want:    github.RepoStats{Name: "bar", Stars: 10, Issues: 15, PullRequests: 5, Forks: 1},
 		},
+		{
+			name: "skip pull request count",
+			ghClient: fakeGitHubClient{
+				repoStats: github.RepoStats{Name: "bar", Stars: 10, Issues: 20, Forks: 1},
+				prCount:   5,
+			},
+			repo:      "foo/bar",
+			skipPulls: true,
+			wantErr:   assert.NoError,
+			want:      github.RepoStats{Name: "bar", Stars: 10, Issues: 20, Forks: 1},
+		},
+		{
+			name: "activity",
+			ghClient: fakeGitHubClient{
+				repoStats: github.RepoStats{Name: "bar", Stars: 10, Issues: 20, Forks: 1},
+				prCount:   5,
+				activity:  github.Activity{Commits: 3, Contributors4w: 1},
+			},
+			repo:     "foo/bar",
+			activity: true,
+			wantErr:  assert.NoError,
+			want:     github.RepoStats{Name: "bar", Stars: 10, Issues: 15, PullRequests: 5, Forks: 1, Activity: &github.Activity{Commits: 3, Contributors4w: 1}},
+		},
+		{
+			name: "compliance",
+			ghClient: fakeGitHubClient{
+				repoStats:  github.RepoStats{Name: "bar", DefaultBranch: "main"},
+				protection: github.BranchProtection{Protected: true, RequiredReviews: true},
+			},
+			repo:       "foo/bar",
+			compliance: true,
+			wantErr:    assert.NoError,
+			want:       github.RepoStats{Name: "bar", DefaultBranch: "main", BranchProtection: &github.BranchProtection{Protected: true, RequiredReviews: true}},
+		},
+		{
+			name: "compliance unavailable",
+			ghClient: fakeGitHubClient{
+				repoStats:     github.RepoStats{Name: "bar", DefaultBranch: "main"},
+				protectionErr: github.ErrProtectionUnavailable,
+			},
+			repo:       "foo/bar",
+			compliance: true,
+			wantErr:    assert.NoError,
+			want:       github.RepoStats{Name: "bar", DefaultBranch: "main"},
+		},
+		{
+			name: "activity not ready",
+			ghClient: fakeGitHubClient{
+				repoStats:   github.RepoStats{Name: "bar", Stars: 10, Issues: 20, Forks: 1},
+				prCount:     5,
+				activityErr: github.ErrStatsNotReady,
+			},
+			repo:     "foo/bar",
+			activity: true,
+			wantErr:  assert.NoError,
+			want:     github.RepoStats{Name: "bar", Stars: 10, Issues: 15, PullRequests: 5, Forks: 1},
+		},
+		{
+			name: "activity error",
+			ghClient: fakeGitHubClient{
+				repoStats:   github.RepoStats{Name: "bar"},
+				activityErr: assert.AnError,
+			},
+			repo:     "foo/bar",
+			activity: true,
+			wantErr:  assert.Error,
+			want:     github.RepoStats{Name: "bar"},
+		},
 		{
 			name:     "error",
 			ghClient: fakeGitHubClient{err: assert.AnError},
@@ -87,8 +274,14 @@ func TestClient_getStats(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := Client{GitHubClient: tt.ghClient, Logger: slog.Default()}
-			count, err := c.getStats(ctx, tt.repo)
+			c := Client{
+				GitHubClient:         tt.ghClient,
+				Logger:               slog.Default(),
+				SkipPullRequestCount: tt.skipPulls,
+				IncludeActivity:      tt.activity,
+				IncludeCompliance:    tt.compliance,
+			}
+			count, err := c.getStats(ctx, tt.repo, newCustomProperties(tt.ghClient))
 			tt.wantErr(t, err)
 			assert.Equal(t, tt.want, count)
 		})
@@ -99,9 +292,20 @@ var _ GitHubClient = &fakeGitHubClient{}
 
 type fakeGitHubClient struct {
 	userRepoNames []string
+	// topicRepoNames holds the repos per org/topic
+	topicRepoNames map[string][]string
+	// teamRepos holds the repos per org/team
+	teamRepos map[string][]github.TeamRepo
+	// properties holds the custom properties per repo
+	properties    map[string]map[string]string
 	repoStats     github.RepoStats
+	activity      github.Activity
+	protection    github.BranchProtection
+	closedPulls   []github.PullRequest
 	prCount       int
 	err           error
+	activityErr   error
+	protectionErr error
 }
 
 func (f fakeGitHubClient) GetUserRepoNames(_ context.Context, _ string) ([]string, error) {
@@ -111,6 +315,33 @@ func (f fakeGitHubClient) GetUserRepoNames(_ context.Context, _ string) ([]strin
 	return f.userRepoNames, nil
 }
 
+func (f fakeGitHubClient) GetTopicRepoNames(_ context.Context, org string, topic string) ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.topicRepoNames[org+"/"+topic], nil
+}
+
+func (f fakeGitHubClient) GetTeamRepos(_ context.Context, org string, team string) ([]github.TeamRepo, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.teamRepos[org+"/"+team], nil
+}
+
+func (f fakeGitHubClient) GetCustomProperties(_ context.Context, org string) (map[string]map[string]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	properties := make(map[string]map[string]string)
+	for repo, values := range f.properties {
+		if strings.HasPrefix(repo, org+"/") {
+			properties[repo] = values
+		}
+	}
+	return properties, nil
+}
+
 func (f fakeGitHubClient) GetRepoStats(_ context.Context, _ string, _ string) (github.RepoStats, error) {
 	if f.err != nil {
 		return github.RepoStats{}, f.err
@@ -124,3 +355,78 @@ func (f fakeGitHubClient) GetPullRequestCount(_ context.Context, _ string, _ str
 	}
 	return f.prCount, nil
 }
+
+func (f fakeGitHubClient) GetActivity(_ context.Context, _ string, _ string) (github.Activity, error) {
+	if f.activityErr != nil {
+		return github.Activity{}, f.activityErr
+	}
+	return f.activity, nil
+}
+
+func (f fakeGitHubClient) GetProtection(_ context.Context, _ string, _ string, _ string) (github.BranchProtection, error) {
+	if f.err != nil {
+		return github.BranchProtection{}, f.err
+	}
+	if f.protectionErr != nil {
+		return github.BranchProtection{}, f.protectionErr
+	}
+	return f.protection, nil
+}
+
+func (f fakeGitHubClient) GetClosedPullRequests(_ context.Context, _ string, _ string, since time.Time) ([]github.PullRequest, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var pulls []github.PullRequest
+	for _, pull := range f.closedPulls {
+		if !pull.UpdatedAt.Before(since) {
+			pulls = append(pulls, pull)
+		}
+	}
+	return pulls, nil
+}
+
+var _ GitHubClient = &propertiesGitHubClient{}
+
+// propertiesGitHubClient records the number of GetCustomProperties calls per org. GetRepoStats returns the repo's name.
+type propertiesGitHubClient struct {
+	fakeGitHubClient
+	calls map[string]int
+	lock  sync.Mutex
+}
+
+func (f *propertiesGitHubClient) GetCustomProperties(ctx context.Context, org string) (map[string]map[string]string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[org]++
+	return f.fakeGitHubClient.GetCustomProperties(ctx, org)
+}
+
+func (f *propertiesGitHubClient) GetRepoStats(_ context.Context, user string, repo string) (github.RepoStats, error) {
+	return github.RepoStats{Name: user + "/" + repo}, nil
+}
+
+var _ GitHubClient = &concurrencyGitHubClient{}
+
+// concurrencyGitHubClient records the maximum number of concurrent GetRepoStats calls.
+type concurrencyGitHubClient struct {
+	fakeGitHubClient
+	current atomic.Int32
+	max     atomic.Int32
+}
+
+func (f *concurrencyGitHubClient) GetRepoStats(_ context.Context, _ string, repo string) (github.RepoStats, error) {
+	current := f.current.Add(1)
+	defer f.current.Add(-1)
+	for {
+		m := f.max.Load()
+		if current <= m || f.max.CompareAndSwap(m, current) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return github.RepoStats{Name: repo}, nil
+}
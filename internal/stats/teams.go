@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+)
+
+// TeamTracker keeps the repos that the Teams have access to. Team access changes rarely, so the repos are only read
+// every Interval, rather than at each refresh.
+type TeamTracker struct {
+	lastUpdate time.Time
+	// repos holds the access of the teams to each repo, by lowercase full repo name.
+	repos    map[string][]github.TeamAccess
+	names    []string
+	Teams    []Team
+	Interval time.Duration
+	lock     sync.Mutex
+}
+
+// Team is a team of an org, identified by its slug.
+type Team struct {
+	Org  string
+	Team string
+}
+
+// update reads the repos of the teams if they are older than Interval and returns the names of the repos. If the repos
+// can't be read, update returns the error with the names of the previous repos, which are kept and read again at the
+// next update.
+func (t *TeamTracker) update(ctx context.Context, c GitHubClient, now time.Time) ([]string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.repos == nil || now.Sub(t.lastUpdate) >= t.Interval {
+		repos := make(map[string][]github.TeamAccess)
+		var names []string
+		for _, team := range t.Teams {
+			teamRepos, err := c.GetTeamRepos(ctx, team.Org, team.Team)
+			if err != nil {
+				return t.names, fmt.Errorf("get repos for team %s/%s: %w", team.Org, team.Team, err)
+			}
+			for _, teamRepo := range teamRepos {
+				key := strings.ToLower(teamRepo.Name)
+				if _, ok := repos[key]; !ok {
+					names = append(names, teamRepo.Name)
+				}
+				repos[key] = append(repos[key], teamRepo.TeamAccess)
+			}
+		}
+		t.repos, t.names = repos, names
+		t.lastUpdate = now
+	}
+	return t.names, nil
+}
+
+// teams returns the access of the teams to the repo.
+func (t *TeamTracker) teams(repo string) []github.TeamAccess {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.repos[strings.ToLower(repo)]
+}
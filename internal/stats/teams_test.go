@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamTracker_update(t *testing.T) {
+	now := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	c := fakeGitHubClient{teamRepos: map[string][]github.TeamRepo{
+		"foo/payments": {
+			{Name: "foo/Bar", TeamAccess: github.TeamAccess{Org: "foo", Team: "payments", Permission: "admin"}},
+			{Name: "foo/snafu", TeamAccess: github.TeamAccess{Org: "foo", Team: "payments", Permission: "read"}},
+		},
+		"foo/platform": {
+			{Name: "foo/Bar", TeamAccess: github.TeamAccess{Org: "foo", Team: "platform", Permission: "write"}},
+		},
+	}}
+	tracker := TeamTracker{Teams: []Team{{Org: "foo", Team: "payments"}, {Org: "foo", Team: "platform"}}, Interval: time.Hour}
+
+	names, err := tracker.update(context.Background(), c, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/Bar", "foo/snafu"}, names)
+	assert.Equal(t, []github.TeamAccess{
+		{Org: "foo", Team: "payments", Permission: "admin"},
+		{Org: "foo", Team: "platform", Permission: "write"},
+	}, tracker.teams("foo/bar"))
+	assert.Empty(t, tracker.teams("foo/other"))
+
+	// teams are not read again until the interval has passed
+	c.teamRepos = nil
+	names, err = tracker.update(context.Background(), c, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, names, 2)
+
+	// if the teams can't be read, the previous repos are kept
+	c.err = assert.AnError
+	names, err = tracker.update(context.Background(), c, now.Add(time.Hour))
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, []string{"foo/Bar", "foo/snafu"}, names)
+	assert.Len(t, tracker.teams("foo/bar"), 2)
+
+	c.err = nil
+	names, err = tracker.update(context.Background(), c, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, names)
+	assert.Empty(t, tracker.teams("foo/bar"))
+}
@@ -0,0 +1,287 @@
+package token
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ prometheus.Collector = &Pool{}
+
+// Pool rotates requests across multiple tokens. Each request uses the token with the most remaining quota. Tokens that
+// are exhausted are skipped until their rate limit resets. Tokens that are rejected by GitHub are skipped for
+// revokedRetry, after which they're tried again, e.g. in case the token was rotated or GitHub rejected it by mistake.
+//
+// Pool exposes the quota of each token as Prometheus metrics. Tokens are identified by a hash, never by the token itself.
+type Pool struct {
+	tokens []*pooledToken
+	lock   sync.Mutex
+}
+
+type pooledToken struct {
+	reset     time.Time
+	token     string
+	id        string
+	limit     int
+	revoked   time.Time
+	remaining int
+}
+
+// usable returns false if GitHub rejected the token less than revokedRetry ago.
+func (t *pooledToken) usable(now time.Time) bool {
+	return t.revoked.IsZero() || !now.Before(t.revoked.Add(revokedRetry))
+}
+
+const (
+	// defaultLimit is the quota of a token that hasn't been used yet.
+	defaultLimit = 5000
+	// revokedRetry is how long a token that was rejected by GitHub is skipped.
+	revokedRetry = 15 * time.Minute
+)
+
+var (
+	tokenLimitMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("github", "exporter", "token_rate_limit"),
+		"Rate limit of the token",
+		[]string{"token"},
+		nil,
+	)
+	tokenRemainingMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("github", "exporter", "token_rate_remaining"),
+		"Remaining requests for the token in the current rate limit window",
+		[]string{"token"},
+		nil,
+	)
+	tokenResetMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("github", "exporter", "token_rate_reset_timestamp_seconds"),
+		"Time when the rate limit window of the token resets",
+		[]string{"token"},
+		nil,
+	)
+	tokenRevokedMetric = prometheus.NewDesc(
+		prometheus.BuildFQName("github", "exporter", "token_revoked"),
+		"Token was rejected by GitHub",
+		[]string{"token"},
+		nil,
+	)
+)
+
+// ErrNoTokens is returned when all tokens in the Pool are exhausted or revoked.
+var ErrNoTokens = errors.New("no usable github tokens")
+
+// NewPool returns a Pool for the tokens. Duplicate tokens are only added once, as they share the same quota.
+func NewPool(tokens ...string) *Pool {
+	var p Pool
+	for _, token := range tokens {
+		if slices.ContainsFunc(p.tokens, func(t *pooledToken) bool { return t.token == token }) {
+			continue
+		}
+		p.tokens = append(p.tokens, &pooledToken{
+			token:     token,
+			id:        hash(token),
+			limit:     defaultLimit,
+			remaining: defaultLimit,
+		})
+	}
+	return &p
+}
+
+// IDs returns the IDs of the tokens that aren't skipped after being rejected by GitHub. The ID of a token is the hash reported in the
+// token label of the Pool's metrics.
+func (p *Pool) IDs() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := time.Now()
+	ids := make([]string, 0, len(p.tokens))
+	for _, token := range p.tokens {
+		if token.usable(now) {
+			ids = append(ids, token.id)
+		}
+	}
+	return ids
+}
+
+type tokenIDKey struct{}
+
+// WithID returns a context that makes the Pool authenticate a request with the token with the id, instead of the token
+// with the most remaining quota, e.g. to query the quota of each token.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tokenIDKey{}, id)
+}
+
+func hash(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])[:12]
+}
+
+// RoundTripper returns an http.RoundTripper that authenticates each request with a token from the Pool. If GitHub rejects
+// the token, or its quota is exhausted, the request is retried with the next token.
+func (p *Pool) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		if id, ok := request.Context().Value(tokenIDKey{}).(string); ok {
+			return p.roundTripWith(next, request, id)
+		}
+		tried := make(map[*pooledToken]struct{}, len(p.tokens))
+		var last *http.Response
+		for {
+			token := p.pick(tried)
+			if token == nil {
+				if last != nil {
+					return last, nil
+				}
+				return nil, ErrNoTokens
+			}
+			tried[token] = struct{}{}
+
+			req, err := cloneRequest(request)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", "Bearer "+token.token)
+			if last != nil {
+				_ = last.Body.Close()
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			if !p.update(token, resp) {
+				return resp, nil
+			}
+			last = resp
+		}
+	})
+}
+
+// roundTripWith sends the request with the token with the id. If no token has the id, it returns ErrNoTokens.
+func (p *Pool) roundTripWith(next http.RoundTripper, request *http.Request, id string) (*http.Response, error) {
+	i := slices.IndexFunc(p.tokens, func(t *pooledToken) bool { return t.id == id })
+	if i < 0 {
+		return nil, ErrNoTokens
+	}
+	req, err := cloneRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.tokens[i].token)
+	resp, err := next.RoundTrip(req)
+	if err == nil {
+		p.update(p.tokens[i], resp)
+	}
+	return resp, err
+}
+
+// pick returns the usable token with the most remaining quota, skipping tokens that have already been tried.
+func (p *Pool) pick(tried map[*pooledToken]struct{}) *pooledToken {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	var best *pooledToken
+	var bestRemaining int
+	for _, token := range p.tokens {
+		if _, ok := tried[token]; ok || !token.usable(now) {
+			continue
+		}
+		remaining := token.remaining
+		if !token.reset.IsZero() && now.After(token.reset) {
+			remaining = token.limit
+		}
+		if remaining <= 0 {
+			continue
+		}
+		if best == nil || remaining > bestRemaining {
+			best, bestRemaining = token, remaining
+		}
+	}
+	if best != nil {
+		// claim one request, so parallel requests spread across tokens
+		best.remaining = bestRemaining - 1
+	}
+	return best
+}
+
+// update records the quota reported in the response. It returns true if the request should be retried with another token.
+func (p *Pool) update(token *pooledToken, resp *http.Response) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit")); err == nil {
+		token.limit = limit
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err == nil {
+		token.remaining = remaining
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		token.reset = time.Unix(reset, 0)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		token.revoked = time.Now()
+		return true
+	}
+	token.revoked = time.Time{}
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		return remaining == 0 && err == nil
+	default:
+		return false
+	}
+}
+
+func cloneRequest(request *http.Request) (*http.Request, error) {
+	req := request.Clone(request.Context())
+	if request.Body != nil && request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	return req, nil
+}
+
+func (p *Pool) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tokenLimitMetric
+	ch <- tokenRemainingMetric
+	ch <- tokenResetMetric
+	ch <- tokenRevokedMetric
+}
+
+func (p *Pool) Collect(ch chan<- prometheus.Metric) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, token := range p.tokens {
+		ch <- prometheus.MustNewConstMetric(tokenLimitMetric, prometheus.GaugeValue, float64(token.limit), token.id)
+		ch <- prometheus.MustNewConstMetric(tokenRemainingMetric, prometheus.GaugeValue, float64(token.remaining), token.id)
+		var reset float64
+		if !token.reset.IsZero() {
+			reset = float64(token.reset.Unix())
+		}
+		ch <- prometheus.MustNewConstMetric(tokenResetMetric, prometheus.GaugeValue, reset, token.id)
+		ch <- prometheus.MustNewConstMetric(tokenRevokedMetric, prometheus.GaugeValue, bool2float(!token.revoked.IsZero()), token.id)
+	}
+}
+
+func bool2float(val bool) float64 {
+	if val {
+		return 1
+	}
+	return 0
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (r roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return r(request)
+}
@@ -0,0 +1,163 @@
+package token
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_RoundTripper(t *testing.T) {
+	remaining := map[string]int{"token-1": 10, "token-2": 20, "token-3": 0}
+	reset := time.Now().Add(time.Hour).Unix()
+	var used []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		used = append(used, token)
+		count, ok := remaining[token]
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(count))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		if count == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		remaining[token] = count - 1
+	}))
+	t.Cleanup(ts.Close)
+
+	p := NewPool("token-1", "token-2", "token-3", "revoked")
+	c := http.Client{Transport: p.RoundTripper(http.DefaultTransport)}
+
+	// all tokens are unknown: first one wins, exhausted and revoked tokens are discovered and skipped
+	for range 4 {
+		resp, err := c.Get(ts.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, []string{"token-1", "token-2", "token-3", "revoked"}, used[:4])
+	assert.Equal(t, "token-2", used[len(used)-1])
+
+	assert.NoError(t, testutil.CollectAndCompare(p, strings.NewReader(`
+# HELP github_exporter_token_revoked Token was rejected by GitHub
+# TYPE github_exporter_token_revoked gauge
+github_exporter_token_revoked{token="`+hash("revoked")+`"} 1
+github_exporter_token_revoked{token="`+hash("token-1")+`"} 0
+github_exporter_token_revoked{token="`+hash("token-2")+`"} 0
+github_exporter_token_revoked{token="`+hash("token-3")+`"} 0
+`), "github_exporter_token_revoked"))
+}
+
+func TestPool_RoundTripper_Exhausted(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(ts.Close)
+
+	p := NewPool("token-1", "token-2")
+	c := http.Client{Transport: p.RoundTripper(http.DefaultTransport)}
+
+	// all tokens exhausted: the last response is returned
+	resp, err := c.Get(ts.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// no tokens left until the reset
+	_, err = c.Get(ts.URL)
+	assert.ErrorIs(t, err, ErrNoTokens)
+}
+
+func TestNewPool_Duplicates(t *testing.T) {
+	p := NewPool("token-1", "token-2", "token-1")
+	assert.Equal(t, 2, testutil.CollectAndCount(p, "github_exporter_token_revoked"))
+	r := prometheus.NewPedanticRegistry()
+	r.MustRegister(p)
+	_, err := r.Gather()
+	assert.NoError(t, err)
+}
+
+func TestPool_WithID(t *testing.T) {
+	var used []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		used = append(used, token)
+		if token == "revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	p := NewPool("token-1", "token-2", "revoked")
+	c := http.Client{Transport: p.RoundTripper(http.DefaultTransport)}
+	for _, id := range p.IDs() {
+		req, _ := http.NewRequestWithContext(WithID(context.Background(), id), http.MethodGet, ts.URL, nil)
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	// each request uses the requested token, without retrying with another token
+	assert.Equal(t, []string{"token-1", "token-2", "revoked"}, used)
+	assert.Equal(t, []string{hash("token-1"), hash("token-2")}, p.IDs())
+
+	req, _ := http.NewRequestWithContext(WithID(context.Background(), "unknown"), http.MethodGet, ts.URL, nil)
+	_, err := c.Do(req)
+	assert.ErrorIs(t, err, ErrNoTokens)
+}
+
+func TestPool_RoundTripper_RevokedRetry(t *testing.T) {
+	revoked := true
+	var used []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		used = append(used, token)
+		if revoked {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	p := NewPool("token-1")
+	c := http.Client{Transport: p.RoundTripper(http.DefaultTransport)}
+
+	resp, err := c.Get(ts.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// the rejected token is skipped ...
+	_, err = c.Get(ts.URL)
+	assert.ErrorIs(t, err, ErrNoTokens)
+	assert.Empty(t, p.IDs())
+
+	// ... until revokedRetry has passed
+	revoked = false
+	p.tokens[0].revoked = time.Now().Add(-revokedRetry)
+	assert.Equal(t, []string{hash("token-1")}, p.IDs())
+	resp, err = c.Get(ts.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"token-1", "token-1"}, used)
+
+	assert.NoError(t, testutil.CollectAndCompare(p, strings.NewReader(`
+# HELP github_exporter_token_revoked Token was rejected by GitHub
+# TYPE github_exporter_token_revoked gauge
+github_exporter_token_revoked{token="`+hash("token-1")+`"} 0
+`), "github_exporter_token_revoked"))
+}
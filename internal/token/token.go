@@ -0,0 +1,120 @@
+package token
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var (
+	_ oauth2.TokenSource = &FileSource{}
+	_ oauth2.TokenSource = EnvSource("")
+	_ oauth2.TokenSource = &CommandSource{}
+)
+
+// FileSource reads the token from a file. The file is re-read whenever its modification time or size changes,
+// so rotated secrets (e.g. Kubernetes secrets mounted as a volume) are picked up without a restart.
+type FileSource struct {
+	token   *oauth2.Token
+	modTime time.Time
+	Path    string
+	size    int64
+	lock    sync.Mutex
+}
+
+func (f *FileSource) Token() (*oauth2.Token, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("token file: %w", err)
+	}
+	if f.token != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.token, nil
+	}
+	content, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("token file: %w", err)
+	}
+	token, err := newToken(content)
+	if err != nil {
+		return nil, fmt.Errorf("token file %s: %w", f.Path, err)
+	}
+	f.token, f.modTime, f.size = token, info.ModTime(), info.Size()
+	return f.token, nil
+}
+
+// EnvSource reads the token from the named environment variable.
+type EnvSource string
+
+func (e EnvSource) Token() (*oauth2.Token, error) {
+	token, err := newToken([]byte(os.Getenv(string(e))))
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", string(e), err)
+	}
+	return token, nil
+}
+
+// CommandSource runs a credential helper and uses its output as the token. The output is cached for TTL.
+type CommandSource struct {
+	expiry  time.Time
+	token   *oauth2.Token
+	Command []string
+	TTL     time.Duration
+	Timeout time.Duration
+	lock    sync.Mutex
+}
+
+const defaultCommandTimeout = 30 * time.Second
+
+func (c *CommandSource) Token() (*oauth2.Token, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.token != nil && time.Now().Before(c.expiry) {
+		return c.token, nil
+	}
+	if len(c.Command) == 0 {
+		return nil, errors.New("token command: no command specified")
+	}
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Command[0], c.Command[1:]...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("token command: %w", err)
+	}
+	token, err := newToken(output)
+	if err != nil {
+		return nil, fmt.Errorf("token command: %w", err)
+	}
+	c.token, c.expiry = token, time.Now().Add(c.TTL)
+	return c.token, nil
+}
+
+func newToken(content []byte) (*oauth2.Token, error) {
+	accessToken := strings.TrimSpace(string(content))
+	if accessToken == "" {
+		return nil, errors.New("empty token")
+	}
+	return &oauth2.Token{AccessToken: accessToken}, nil
+}
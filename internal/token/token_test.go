@@ -0,0 +1,75 @@
+package token
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("token-1\n"), 0600))
+
+	s := FileSource{Path: path}
+	token, err := s.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+
+	require.NoError(t, os.WriteFile(path, []byte("token-22\n"), 0600))
+	token, err = s.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-22", token.AccessToken)
+
+	require.NoError(t, os.WriteFile(path, []byte("\n"), 0600))
+	_, err = s.Token()
+	assert.Error(t, err)
+
+	require.NoError(t, os.Remove(path))
+	_, err = s.Token()
+	assert.Error(t, err)
+}
+
+func TestEnvSource(t *testing.T) {
+	t.Setenv("GITHUB_EXPORTER_TEST_TOKEN", "token")
+	token, err := EnvSource("GITHUB_EXPORTER_TEST_TOKEN").Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token", token.AccessToken)
+
+	_, err = EnvSource("GITHUB_EXPORTER_TEST_TOKEN_MISSING").Token()
+	assert.Error(t, err)
+}
+
+func TestCommandSource(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	s := CommandSource{
+		Command: []string{"sh", "-c", `echo x >> ` + counter + `; echo "token-$(wc -l < ` + counter + ` | tr -d ' ')"`},
+		TTL:     time.Hour,
+	}
+
+	token, err := s.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+
+	// cached
+	token, err = s.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+
+	// expired
+	s.expiry = time.Now()
+	token, err = s.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token.AccessToken)
+
+	s = CommandSource{Command: []string{"sh", "-c", "echo failed >&2; exit 1"}}
+	_, err = s.Token()
+	assert.ErrorContains(t, err, "failed")
+
+	s = CommandSource{}
+	_, err = s.Token()
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config configures the span exporter. Exporter is either otlp or stdout. For otlp, Protocol selects grpc or http.
+// If Endpoint is blank, the exporter uses the standard OTEL_EXPORTER_OTLP_* environment variables.
+type Config struct {
+	Headers  map[string]string
+	Exporter string
+	Protocol string
+	Endpoint string
+	Insecure bool
+}
+
+// NewTracerProvider returns a TracerProvider that exports spans as configured. The caller must call Shutdown
+// on the returned TracerProvider to flush any remaining spans.
+func NewTracerProvider(ctx context.Context, cfg Config, r *resource.Resource) (*sdktrace.TracerProvider, error) {
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(r),
+	), nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		switch cfg.Protocol {
+		case "grpc":
+			var opts []otlptracegrpc.Option
+			if cfg.Endpoint != "" {
+				opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+			}
+			if cfg.Insecure {
+				opts = append(opts, otlptracegrpc.WithInsecure())
+			}
+			if len(cfg.Headers) > 0 {
+				opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+			}
+			return otlptracegrpc.New(ctx, opts...)
+		case "http":
+			var opts []otlptracehttp.Option
+			if cfg.Endpoint != "" {
+				opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+			}
+			if cfg.Insecure {
+				opts = append(opts, otlptracehttp.WithInsecure())
+			}
+			if len(cfg.Headers) > 0 {
+				opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+			}
+			return otlptracehttp.New(ctx, opts...)
+		default:
+			return nil, fmt.Errorf("invalid otlp protocol: %q", cfg.Protocol)
+		}
+	default:
+		return nil, fmt.Errorf("invalid tracing exporter: %q", cfg.Exporter)
+	}
+}
+
+// RoundTripper returns an http.RoundTripper that creates a client span for each request. The GitHub rate limit headers
+// of the response are added to the span as attributes.
+func RoundTripper(next http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(
+		roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(request)
+			if err == nil {
+				trace.SpanFromContext(request.Context()).SetAttributes(rateLimitAttributes(resp.Header)...)
+			}
+			return resp, err
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+}
+
+var rateLimitHeaders = []struct {
+	header    string
+	attribute string
+}{
+	{header: "X-RateLimit-Limit", attribute: "github.ratelimit.limit"},
+	{header: "X-RateLimit-Remaining", attribute: "github.ratelimit.remaining"},
+	{header: "X-RateLimit-Used", attribute: "github.ratelimit.used"},
+	{header: "X-RateLimit-Reset", attribute: "github.ratelimit.reset"},
+}
+
+func rateLimitAttributes(h http.Header) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(rateLimitHeaders)+1)
+	for _, rl := range rateLimitHeaders {
+		if value, err := strconv.ParseInt(h.Get(rl.header), 10, 64); err == nil {
+			attrs = append(attrs, attribute.Int64(rl.attribute, value))
+		}
+	}
+	if rateResource := h.Get("X-RateLimit-Resource"); rateResource != "" {
+		attrs = append(attrs, attribute.String("github.ratelimit.resource", rateResource))
+	}
+	return attrs
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (r roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return r(request)
+}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRoundTripper(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Used", "1")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.Header().Set("X-RateLimit-Resource", "core")
+	}))
+	t.Cleanup(ts.Close)
+
+	c := http.Client{Transport: RoundTripper(http.DefaultTransport)}
+	resp, err := c.Get(ts.URL + "/repos/foo/bar")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /repos/foo/bar", spans[0].Name())
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, attr := range spans[0].Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	assert.Equal(t, int64(5000), attrs["github.ratelimit.limit"].AsInt64())
+	assert.Equal(t, int64(4999), attrs["github.ratelimit.remaining"].AsInt64())
+	assert.Equal(t, int64(1), attrs["github.ratelimit.used"].AsInt64())
+	assert.Equal(t, int64(1700000000), attrs["github.ratelimit.reset"].AsInt64())
+	assert.Equal(t, "core", attrs["github.ratelimit.resource"].AsString())
+}
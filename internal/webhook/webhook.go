@@ -0,0 +1,159 @@
+// Package webhook receives GitHub webhook events and applies them to the collector's cache, so metrics are updated
+// between refreshes.
+package webhook
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+	gogithub "github.com/google/go-github/v89/github"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ prometheus.Collector = &Handler{}
+var _ http.Handler = &Handler{}
+
+// Cache holds the repo statistics that are updated by webhook events.
+type Cache interface {
+	Update(string, string, func(*github.RepoStats)) bool
+}
+
+// Handler handles GitHub webhook events. It verifies each event's X-Hub-Signature-256 signature and applies
+// star, fork, issues, pull_request, release and workflow_run events to the cache of the affected repo.
+type Handler struct {
+	Cache    Cache
+	Logger   *slog.Logger
+	requests *prometheus.CounterVec
+	Secret   []byte
+}
+
+// Results of handling a webhook request.
+const (
+	ResultUpdated  = "updated"
+	ResultIgnored  = "ignored"
+	ResultRejected = "rejected"
+)
+
+// maxPayloadSize is the maximum size of a webhook payload. GitHub caps payloads at 25 MB.
+const maxPayloadSize = 25 << 20
+
+func New(cache Cache, secret []byte, logger *slog.Logger) *Handler {
+	return &Handler{
+		Cache:  cache,
+		Secret: secret,
+		Logger: logger,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: prometheus.BuildFQName("github", "exporter", "webhook_requests_total"),
+			Help: "Total number of received webhook requests",
+		}, []string{"event", "result"}),
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	event := gogithub.WebHookType(r)
+	if r.Method != http.MethodPost {
+		h.reject(w, event, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		h.reject(w, event, http.StatusBadRequest, err)
+		return
+	}
+	if err = gogithub.ValidateSignature(r.Header.Get(gogithub.SHA256SignatureHeader), payload, h.Secret); err != nil {
+		h.reject(w, event, http.StatusUnauthorized, err)
+		return
+	}
+	parsed, err := gogithub.ParseWebHook(event, payload)
+	if err != nil {
+		h.reject(w, event, http.StatusBadRequest, err)
+		return
+	}
+
+	result := ResultIgnored
+	if host, repo, update := updateFor(parsed); update != nil && h.Cache.Update(host, repo, update) {
+		result = ResultUpdated
+	}
+	h.Logger.Debug("webhook event received", "event", event, "result", result)
+	h.requests.WithLabelValues(event, result).Inc()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) reject(w http.ResponseWriter, event string, statusCode int, err error) {
+	h.Logger.Warn("invalid webhook request", "event", event, "err", err)
+	h.requests.WithLabelValues(event, ResultRejected).Inc()
+	http.Error(w, http.StatusText(statusCode), statusCode)
+}
+
+// updateFor returns the host and repo affected by the event and the update to apply to its statistics. The update is nil
+// if the event is not supported.
+func updateFor(event any) (string, string, func(*github.RepoStats)) {
+	var repo *gogithub.Repository
+	var pullRequestDelta int
+	switch e := event.(type) {
+	case *gogithub.StarEvent:
+		repo = e.GetRepo()
+	case *gogithub.ForkEvent:
+		repo = e.GetRepo()
+	case *gogithub.IssuesEvent:
+		repo = e.GetRepo()
+	case *gogithub.PullRequestEvent:
+		repo = e.GetRepo()
+		switch e.GetAction() {
+		case "opened", "reopened":
+			pullRequestDelta = 1
+		case "closed":
+			pullRequestDelta = -1
+		}
+	case *gogithub.ReleaseEvent:
+		repo = e.GetRepo()
+	case *gogithub.WorkflowRunEvent:
+		repo = e.GetRepo()
+	}
+	if repo == nil {
+		return "", "", nil
+	}
+	// the host of the repo's web URL matches the host of its source
+	return github.HostName(repo.GetHTMLURL()), repo.GetFullName(), func(repoStats *github.RepoStats) {
+		apply(repoStats, repo, pullRequestDelta)
+	}
+}
+
+// apply updates the repo statistics with the repository included in the event. The repository's open_issues_count
+// includes open pull requests, which aren't reported in the event, so these are updated by counting opened and
+// closed pull requests.
+func apply(repoStats *github.RepoStats, repo *gogithub.Repository, pullRequestDelta int) {
+	repoStats.PullRequests = max(0, repoStats.PullRequests+pullRequestDelta)
+	if repo.StargazersCount != nil {
+		repoStats.Stars = repo.GetStargazersCount()
+	}
+	if repo.ForksCount != nil {
+		repoStats.Forks = repo.GetForksCount()
+	}
+	if repo.OpenIssuesCount != nil {
+		repoStats.Issues = max(0, repo.GetOpenIssuesCount()-repoStats.PullRequests)
+	}
+	if repo.Archived != nil {
+		repoStats.Archived = repo.GetArchived()
+	}
+	if repo.Size != nil {
+		repoStats.Size = int64(repo.GetSize()) * 1024
+	}
+	if repo.PushedAt != nil {
+		repoStats.PushedAt = repo.GetPushedAt().Time
+	}
+	if repo.UpdatedAt != nil {
+		repoStats.UpdatedAt = repo.GetUpdatedAt().Time
+	}
+}
+
+func (h *Handler) Describe(ch chan<- *prometheus.Desc) {
+	h.requests.Describe(ch)
+}
+
+func (h *Handler) Collect(ch chan<- prometheus.Metric) {
+	h.requests.Collect(ch)
+}
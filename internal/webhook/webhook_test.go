@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     string
+		payload   string
+		signature string
+		want      int
+		wantStats github.RepoStats
+	}{
+		{
+			name:      "star",
+			event:     "star",
+			payload:   `{"action":"created","repository":{"name":"bar","full_name":"foo/bar","html_url":"https://github.com/foo/bar","stargazers_count":11,"forks_count":2,"open_issues_count":8}}`,
+			want:      http.StatusNoContent,
+			wantStats: github.RepoStats{Name: "bar", Stars: 11, Forks: 2, Issues: 5, PullRequests: 3},
+		},
+		{
+			name:      "pull request opened",
+			event:     "pull_request",
+			payload:   `{"action":"opened","repository":{"name":"bar","full_name":"foo/bar","stargazers_count":10,"open_issues_count":9}}`,
+			want:      http.StatusNoContent,
+			wantStats: github.RepoStats{Name: "bar", Stars: 10, Forks: 1, Issues: 5, PullRequests: 4},
+		},
+		{
+			name:      "pull request closed",
+			event:     "pull_request",
+			payload:   `{"action":"closed","repository":{"name":"bar","full_name":"foo/bar","open_issues_count":7}}`,
+			want:      http.StatusNoContent,
+			wantStats: github.RepoStats{Name: "bar", Stars: 10, Forks: 1, Issues: 5, PullRequests: 2},
+		},
+		{
+			name:      "other repo",
+			event:     "star",
+			payload:   `{"action":"created","repository":{"name":"snafu","full_name":"foo/snafu","stargazers_count":11}}`,
+			want:      http.StatusNoContent,
+			wantStats: github.RepoStats{Name: "bar", Stars: 10, Forks: 1, Issues: 5, PullRequests: 3},
+		},
+		{
+			name:      "other host",
+			event:     "star",
+			payload:   `{"action":"created","repository":{"name":"bar","full_name":"foo/bar","html_url":"https://github.example.com/foo/bar","stargazers_count":11}}`,
+			want:      http.StatusNoContent,
+			wantStats: github.RepoStats{Name: "bar", Stars: 10, Forks: 1, Issues: 5, PullRequests: 3},
+		},
+		{
+			name:      "ping",
+			event:     "ping",
+			payload:   `{"zen":"Keep it logically awesome."}`,
+			want:      http.StatusNoContent,
+			wantStats: github.RepoStats{Name: "bar", Stars: 10, Forks: 1, Issues: 5, PullRequests: 3},
+		},
+		{
+			name:      "invalid signature",
+			event:     "star",
+			payload:   `{"action":"created","repository":{"name":"bar","full_name":"foo/bar","stargazers_count":11}}`,
+			signature: "sha256=0000",
+			want:      http.StatusUnauthorized,
+			wantStats: github.RepoStats{Name: "bar", Stars: 10, Forks: 1, Issues: 5, PullRequests: 3},
+		},
+		{
+			name:      "invalid payload",
+			event:     "star",
+			payload:   `not json`,
+			want:      http.StatusBadRequest,
+			wantStats: github.RepoStats{Name: "bar", Stars: 10, Forks: 1, Issues: 5, PullRequests: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := fakeCache{"github.com/foo/bar": {Name: "bar", Stars: 10, Forks: 1, Issues: 5, PullRequests: 3}}
+			h := New(cache, []byte("secret"), slog.Default())
+
+			signature := tt.signature
+			if signature == "" {
+				signature = sign([]byte("secret"), tt.payload)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.payload))
+			req.Header.Set("X-GitHub-Event", tt.event)
+			req.Header.Set("X-Hub-Signature-256", signature)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, tt.wantStats, cache["github.com/foo/bar"])
+			assert.Equal(t, 1, testutil.CollectAndCount(h))
+		})
+	}
+}
+
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+var _ Cache = fakeCache{}
+
+// fakeCache holds the repo statistics by <host>/<owner>/<repo>.
+type fakeCache map[string]github.RepoStats
+
+func (f fakeCache) Update(host string, repo string, update func(*github.RepoStats)) bool {
+	repoStats, ok := f[host+"/"+repo]
+	if !ok {
+		return false
+	}
+	update(&repoStats)
+	f[host+"/"+repo] = repoStats
+	return true
+}
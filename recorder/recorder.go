@@ -0,0 +1,183 @@
+// Package recorder records GitHub API traffic to a directory and replays it, so a user's data can be reproduced offline.
+//
+// Each request/response pair is stored as a JSON file. Credentials are stripped from the recording and requests are
+// recorded without their host, so recordings made against one GitHub host can be replayed against any base URL.
+package recorder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Recording is a recorded request/response pair.
+type Recording struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Header http.Header `json:"header,omitempty"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+}
+
+type Response struct {
+	Header     http.Header     `json:"header,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Text       string          `json:"text,omitempty"`
+	StatusCode int             `json:"status_code"`
+}
+
+// sensitiveHeaders are stripped from the recordings.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// Recorder records the requests sent through its RoundTripper to Dir.
+type Recorder struct {
+	Dir string
+}
+
+func (r Recorder) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(request)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		recording := Recording{
+			Request: Request{
+				Method: request.Method,
+				URL:    requestURL(request),
+				Header: sanitize(request.Header),
+			},
+			Response: Response{
+				StatusCode: resp.StatusCode,
+				Header:     sanitize(resp.Header),
+			},
+		}
+		if json.Valid(body) {
+			recording.Response.Body = body
+		} else {
+			recording.Response.Text = string(body)
+		}
+		if err = r.write(recording); err != nil {
+			return nil, fmt.Errorf("record: %w", err)
+		}
+		return resp, nil
+	})
+}
+
+// write stores the recording. Later recordings of the same request replace earlier ones.
+func (r Recorder) write(recording Recording) error {
+	content, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(r.Dir, fileName(recording.Request.Method, recording.Request.URL))
+	tmp, err := os.CreateTemp(r.Dir, ".recording-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err = tmp.Write(append(content, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Replayer serves the recordings in Dir. Requests without a recording fail with an error.
+type Replayer struct {
+	Dir string
+}
+
+var _ http.RoundTripper = Replayer{}
+
+func (r Replayer) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil {
+		_ = request.Body.Close()
+	}
+	content, err := os.ReadFile(filepath.Join(r.Dir, fileName(request.Method, requestURL(request))))
+	if err != nil {
+		return nil, fmt.Errorf("replay: no recording for %s %s: %w", request.Method, requestURL(request), err)
+	}
+	var recording Recording
+	if err = json.Unmarshal(content, &recording); err != nil {
+		return nil, fmt.Errorf("replay: invalid recording for %s %s: %w", request.Method, requestURL(request), err)
+	}
+	body := []byte(recording.Response.Text)
+	if len(recording.Response.Body) > 0 {
+		// recordings are indented for readability. Serve the body compacted, as GitHub does.
+		var compacted bytes.Buffer
+		if err = json.Compact(&compacted, recording.Response.Body); err != nil {
+			return nil, fmt.Errorf("replay: invalid recording for %s %s: %w", request.Method, requestURL(request), err)
+		}
+		body = compacted.Bytes()
+	}
+	header := recording.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recording.Response.StatusCode, http.StatusText(recording.Response.StatusCode)),
+		StatusCode:    recording.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}, nil
+}
+
+// requestURL returns the path and query of the request. The host is omitted, so recordings can be replayed against
+// any base URL.
+func requestURL(request *http.Request) string {
+	return request.URL.RequestURI()
+}
+
+// fileName returns the name of the recording of a request. The name starts with a readable version of the request,
+// followed by a hash of the request, to keep it unique.
+func fileName(method string, requestURL string) string {
+	hash := sha256.Sum256([]byte(method + " " + requestURL))
+	path, _, _ := strings.Cut(requestURL, "?")
+	readable := strings.Trim(strings.NewReplacer("/", "_", ".", "_").Replace(path), "_")
+	const maxReadable = 100
+	if len(readable) > maxReadable {
+		readable = readable[:maxReadable]
+	}
+	return method + "_" + readable + "_" + hex.EncodeToString(hash[:])[:12] + ".json"
+}
+
+func sanitize(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range sensitiveHeaders {
+		header.Del(name)
+	}
+	return header
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (r roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return r(request)
+}
@@ -0,0 +1,67 @@
+package recorder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		switch r.URL.Path {
+		case "/repos/foo/bar":
+			_, _ = w.Write([]byte(`{"name":"bar","stargazers_count":10}`))
+		case "/text":
+			_, _ = w.Write([]byte("hello"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	dir := t.TempDir()
+	recordClient := &http.Client{Transport: Recorder{Dir: dir}.RoundTripper(http.DefaultTransport)}
+	replayClient := &http.Client{Transport: Replayer{Dir: dir}}
+
+	for _, path := range []string{"/repos/foo/bar?page=2", "/text", "/missing"} {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		want, err := recordClient.Do(req)
+		require.NoError(t, err)
+		wantBody, _ := io.ReadAll(want.Body)
+		_ = want.Body.Close()
+
+		// recordings are replayed regardless of the host
+		got, err := replayClient.Get("https://api.github.com" + path)
+		require.NoError(t, err, path)
+		gotBody, _ := io.ReadAll(got.Body)
+		_ = got.Body.Close()
+
+		assert.Equal(t, want.StatusCode, got.StatusCode, path)
+		assert.Equal(t, string(wantBody), string(gotBody), path)
+		assert.Equal(t, want.Header.Get("X-RateLimit-Remaining"), got.Header.Get("X-RateLimit-Remaining"), path)
+		assert.Empty(t, got.Header.Get("Set-Cookie"), path)
+	}
+
+	// credentials are not recorded
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "secret")
+	}
+
+	// requests without a recording fail
+	_, err = replayClient.Get("https://api.github.com/repos/foo/bar")
+	assert.ErrorContains(t, err, "replay: no recording for GET /repos/foo/bar")
+}
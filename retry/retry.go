@@ -0,0 +1,189 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ prometheus.Collector = &Retrier{}
+
+// Retrier retries requests that failed with a transient error: a 5xx response, a connection reset or a timeout.
+// Requests are retried with jittered exponential backoff, up to MaxAttempts attempts in total.
+type Retrier struct {
+	retries     *prometheus.CounterVec
+	exhausted   prometheus.Counter
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	// Timeout limits the duration of each attempt, including reading the response body. Attempts that time out are
+	// retried. If zero, attempts are only limited by the request's context.
+	Timeout time.Duration
+}
+
+// Reasons for retrying a request.
+const (
+	ReasonServerError     = "server_error"
+	ReasonConnectionReset = "connection_reset"
+	ReasonTimeout         = "timeout"
+)
+
+func New(maxAttempts int, backoff time.Duration, maxBackoff time.Duration) *Retrier {
+	return &Retrier{
+		MaxAttempts: maxAttempts,
+		Backoff:     backoff,
+		MaxBackoff:  maxBackoff,
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: prometheus.BuildFQName("github", "exporter", "api_retries_total"),
+			Help: "Total number of retried GitHub API requests",
+		}, []string{"reason"}),
+		exhausted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: prometheus.BuildFQName("github", "exporter", "api_retries_exhausted_total"),
+			Help: "Total number of GitHub API requests that failed after all retries",
+		}),
+	}
+}
+
+func (r *Retrier) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		backoff := r.Backoff
+		for attempt := 1; ; attempt++ {
+			req, err := rewind(request, attempt)
+			if err != nil {
+				return nil, err
+			}
+			ctx, cancel := r.attemptContext(request.Context())
+			resp, err := next.RoundTrip(req.WithContext(ctx))
+			reason := retryReason(request.Context(), ctx, resp, err)
+			if reason == "" {
+				return cancelOnClose(resp, cancel), err
+			}
+			if attempt >= r.MaxAttempts {
+				r.exhausted.Inc()
+				return cancelOnClose(resp, cancel), err
+			}
+			if resp != nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+			}
+			cancel()
+			r.retries.WithLabelValues(reason).Inc()
+
+			select {
+			case <-request.Context().Done():
+				return nil, request.Context().Err()
+			case <-time.After(Jitter(backoff)):
+			}
+			backoff = min(2*backoff, r.MaxBackoff)
+		}
+	})
+}
+
+// attemptContext returns the context of an attempt, limited to the Retrier's Timeout.
+func (r *Retrier) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.Timeout > 0 {
+		return context.WithTimeout(ctx, r.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// cancelOnClose cancels the context of the attempt when the response body is closed. Without a response, the context
+// is cancelled immediately.
+func cancelOnClose(resp *http.Response, cancel context.CancelFunc) *http.Response {
+	if resp == nil {
+		cancel()
+		return nil
+	}
+	resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// rewind returns the request to send for the attempt. For retries, the request body is recreated.
+func rewind(request *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || request.Body == nil || request.Body == http.NoBody {
+		return request, nil
+	}
+	if request.GetBody == nil {
+		return nil, errors.New("retry: request body cannot be replayed")
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	req := request.Clone(request.Context())
+	req.Body = body
+	return req, nil
+}
+
+// retryReason returns why the request should be retried, or an empty string if it shouldn't. ctx is the context of
+// the request and attemptCtx the context of the attempt.
+func retryReason(ctx context.Context, attemptCtx context.Context, resp *http.Response, err error) string {
+	if ctx.Err() != nil {
+		// request was cancelled or timed out by the caller
+		return ""
+	}
+	if err != nil && attemptCtx.Err() != nil {
+		// attempt timed out
+		return ReasonTimeout
+	}
+	if err == nil {
+		switch resp.StatusCode {
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return ReasonServerError
+		default:
+			return ""
+		}
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return ReasonConnectionReset
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ReasonTimeout
+	default:
+		return ""
+	}
+}
+
+// Jitter returns a random duration between d/2 and d, so that clients that back off at the same time don't retry at the
+// same time.
+func Jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func (r *Retrier) Describe(ch chan<- *prometheus.Desc) {
+	r.retries.Describe(ch)
+	r.exhausted.Describe(ch)
+}
+
+func (r *Retrier) Collect(ch chan<- prometheus.Metric) {
+	r.retries.Collect(ch)
+	r.exhausted.Collect(ch)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (r roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return r(request)
+}
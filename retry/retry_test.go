@@ -0,0 +1,167 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetrier_RoundTripper(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int32
+		statusCode int
+		want       int
+		wantCalls  int32
+		metrics    string
+	}{
+		{
+			name:       "success",
+			statusCode: http.StatusBadGateway,
+			want:       http.StatusOK,
+			wantCalls:  1,
+			metrics: `
+# HELP github_exporter_api_retries_exhausted_total Total number of GitHub API requests that failed after all retries
+# TYPE github_exporter_api_retries_exhausted_total counter
+github_exporter_api_retries_exhausted_total 0
+`,
+		},
+		{
+			name:       "retried",
+			failures:   2,
+			statusCode: http.StatusBadGateway,
+			want:       http.StatusOK,
+			wantCalls:  3,
+			metrics: `
+# HELP github_exporter_api_retries_exhausted_total Total number of GitHub API requests that failed after all retries
+# TYPE github_exporter_api_retries_exhausted_total counter
+github_exporter_api_retries_exhausted_total 0
+# HELP github_exporter_api_retries_total Total number of retried GitHub API requests
+# TYPE github_exporter_api_retries_total counter
+github_exporter_api_retries_total{reason="server_error"} 2
+`,
+		},
+		{
+			name:       "exhausted",
+			failures:   5,
+			statusCode: http.StatusServiceUnavailable,
+			want:       http.StatusServiceUnavailable,
+			wantCalls:  3,
+			metrics: `
+# HELP github_exporter_api_retries_exhausted_total Total number of GitHub API requests that failed after all retries
+# TYPE github_exporter_api_retries_exhausted_total counter
+github_exporter_api_retries_exhausted_total 1
+# HELP github_exporter_api_retries_total Total number of retried GitHub API requests
+# TYPE github_exporter_api_retries_total counter
+github_exporter_api_retries_total{reason="server_error"} 2
+`,
+		},
+		{
+			name:       "not retried",
+			failures:   5,
+			statusCode: http.StatusNotFound,
+			want:       http.StatusNotFound,
+			wantCalls:  1,
+			metrics: `
+# HELP github_exporter_api_retries_exhausted_total Total number of GitHub API requests that failed after all retries
+# TYPE github_exporter_api_retries_exhausted_total counter
+github_exporter_api_retries_exhausted_total 0
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(tt.statusCode)
+				}
+			}))
+			t.Cleanup(ts.Close)
+
+			r := New(3, time.Millisecond, 10*time.Millisecond)
+			c := http.Client{Transport: r.RoundTripper(http.DefaultTransport)}
+			resp, err := c.Post(ts.URL, "text/plain", strings.NewReader("body"))
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, tt.want, resp.StatusCode)
+			assert.Equal(t, tt.wantCalls, calls.Load())
+			assert.NoError(t, testutil.CollectAndCompare(r, strings.NewReader(tt.metrics)))
+		})
+	}
+}
+
+func TestRetrier_RoundTripper_ConnectionReset(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			_ = conn.Close()
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	r := New(3, time.Millisecond, 10*time.Millisecond)
+	c := http.Client{Transport: r.RoundTripper(&http.Transport{DisableKeepAlives: true})}
+	resp, err := c.Get(ts.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), testutil.ToFloat64(r.retries.WithLabelValues(ReasonConnectionReset)))
+}
+
+func TestRetrier_RoundTripper_Cancelled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(ts.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+
+	r := New(10, time.Hour, time.Hour)
+	start := time.Now()
+	_, err := r.RoundTripper(http.DefaultTransport).RoundTrip(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetrier_RoundTripper_Timeout(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// first attempt hangs
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(ts.Close)
+
+	r := New(3, time.Millisecond, 10*time.Millisecond)
+	r.Timeout = 100 * time.Millisecond
+	c := http.Client{Transport: r.RoundTripper(http.DefaultTransport)}
+	resp, err := c.Get(ts.URL)
+	require.NoError(t, err)
+	// the body can be read after the attempt returned
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, float64(1), testutil.ToFloat64(r.retries.WithLabelValues(ReasonTimeout)))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/clambin/github-exporter/internal/collector"
+	"github.com/clambin/github-exporter/internal/config"
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/clambin/github-exporter/retry"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration file",
+	Args:  cobra.NoArgs,
+	Run:   Validate,
+}
+
+func Validate(cmd *cobra.Command, _ []string) {
+	online, _ := cmd.Flags().GetBool("online")
+	if err := validate(cmd.Context(), viper.GetViper(), online); err != nil {
+		for _, msg := range strings.Split(err.Error(), "\n") {
+			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), msg)
+		}
+		os.Exit(1)
+	}
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), "configuration OK")
+}
+
+func validate(ctx context.Context, v *viper.Viper, online bool) error {
+	cfg, err := config.Load(v)
+	if err != nil {
+		return err
+	}
+	if err = errors.Join(cfg.Validate(), validateFamilies(cfg.Metrics)); err != nil || !online {
+		return err
+	}
+
+	var errs []error
+	for _, source := range cfg.AllSources() {
+		if err = checkSource(ctx, source, cfg.Git); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.ID(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateFamilies verifies that the metrics section only selects families that the collector reports.
+func validateFamilies(m config.Metrics) error {
+	families := collector.FamilyNames()
+	var errs []error
+	for _, family := range m.Enabled {
+		if !slices.Contains(families, family) {
+			errs = append(errs, fmt.Errorf("metrics.enabled: unknown metric family %q", family))
+		}
+	}
+	for _, family := range m.Disabled {
+		if !slices.Contains(families, family) {
+			errs = append(errs, fmt.Errorf("metrics.disabled: unknown metric family %q", family))
+		}
+	}
+	for _, family := range slices.Sorted(maps.Keys(m.MaxSeries)) {
+		if !slices.Contains(families, family) {
+			errs = append(errs, fmt.Errorf("metrics.max_series: unknown metric family %q", family))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkSource verifies that the source's token works and that all its users, repos, topics and teams can be reached.
+// API calls are retried and time out as configured in git.
+func checkSource(ctx context.Context, source config.Source, git config.Git) error {
+	tp, _, err := newTokenTransport(source, prometheus.NewRegistry())
+	if err != nil {
+		return err
+	}
+	rt := retry.New(git.Retry.Attempts, git.Retry.Backoff, git.Retry.MaxBackoff)
+	rt.Timeout = git.Timeout
+	ghc, err := github.New(rt.RoundTripper(tp), source.URL)
+	if err != nil {
+		return err
+	}
+	return checkAccess(ctx, ghc, source.Repos)
+}
+
+type accessChecker interface {
+	GetUserRepoNames(context.Context, string) ([]string, error)
+	GetTopicRepoNames(context.Context, string, string) ([]string, error)
+	GetTeamRepos(context.Context, string, string) ([]github.TeamRepo, error)
+	GetRepoStats(context.Context, string, string) (github.RepoStats, error)
+}
+
+// checkAccess verifies that the token works and that all configured users, repos, topics and teams can be reached.
+func checkAccess(ctx context.Context, c accessChecker, repos config.SourceRepos) error {
+	var errs []error
+	for _, user := range repos.User {
+		if _, err := c.GetUserRepoNames(ctx, user); err != nil {
+			errs = append(errs, fmt.Errorf("repos.user: %s: %w", user, err))
+		}
+	}
+	for _, repo := range repos.Repo {
+		owner, name, _ := strings.Cut(repo, "/")
+		if _, err := c.GetRepoStats(ctx, owner, name); err != nil {
+			errs = append(errs, fmt.Errorf("repos.repo: %s: %w", repo, err))
+		}
+	}
+	for _, topic := range repos.Topics {
+		if _, err := c.GetTopicRepoNames(ctx, topic.Org, topic.Topic); err != nil {
+			errs = append(errs, fmt.Errorf("repos.topics: %s/%s: %w", topic.Org, topic.Topic, err))
+		}
+	}
+	for _, team := range repos.Teams {
+		if _, err := c.GetTeamRepos(ctx, team.Org, team.Team); err != nil {
+			errs = append(errs, fmt.Errorf("repos.teams: %s/%s: %w", team.Org, team.Team, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func init() {
+	validateCmd.Flags().Bool("online", false, "Verify that the token works and all configured repos are reachable")
+	cmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/clambin/github-exporter/internal/config"
+	"github.com/clambin/github-exporter/internal/stats/github"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			content: `
+addr: :9090
+repos:
+  repo: [ clambin/github-exporter ]
+git:
+  token: foo
+  cache: 1h
+  timeout: 10s
+  max_concurrent_requests: 25
+  max_concurrent_repos: 10
+  retry:
+    attempts: 3
+    backoff: 1s
+    max_backoff: 10s
+`,
+		},
+		{
+			name: "unknown key",
+			content: `
+repo:
+  repo: [ clambin/github-exporter ]
+`,
+			wantErr: "invalid keys: repo",
+		},
+		{
+			name: "invalid repo",
+			content: `
+addr: :9090
+repos:
+  repo: [ clambin ]
+git:
+  token: foo
+  cache: 1h
+`,
+			wantErr: `invalid repo name "clambin"`,
+		},
+		{
+			name: "unknown metric families",
+			content: `
+repos:
+  repo: [ clambin/github-exporter ]
+git:
+  token: foo
+  cache: 1h
+metrics:
+  enabled: [ stars ]
+  disabled: [ github_exporter_star ]
+  max_series:
+    github_exporter_repo_team: 1000
+    pulls: 10
+`,
+			wantErr: `metrics.enabled: unknown metric family "stars"
+metrics.disabled: unknown metric family "github_exporter_star"
+metrics.max_series: unknown metric family "pulls"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.SetConfigType("yaml")
+			require.NoError(t, v.ReadConfig(bytes.NewBufferString(tt.content)))
+			err := validate(context.Background(), v, false)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCheckAccess(t *testing.T) {
+	c := fakeAccessChecker{users: []string{"foo"}, repos: []string{"foo/bar"}}
+	assert.NoError(t, checkAccess(context.Background(), c, config.SourceRepos{
+		User:   []string{"foo"},
+		Repo:   []string{"foo/bar"},
+		Topics: []config.Topic{{Org: "foo", Topic: "team-a"}},
+		Teams:  []config.Team{{Org: "foo", Team: "payments"}},
+	}))
+
+	err := checkAccess(context.Background(), c, config.SourceRepos{
+		User:   []string{"bar"},
+		Repo:   []string{"foo/snafu"},
+		Topics: []config.Topic{{Org: "bar", Topic: "team-a"}},
+		Teams:  []config.Team{{Org: "bar", Team: "payments"}},
+	})
+	assert.ErrorContains(t, err, "repos.user: bar: not found")
+	assert.ErrorContains(t, err, "repos.repo: foo/snafu: not found")
+	assert.ErrorContains(t, err, "repos.topics: bar/team-a: not found")
+	assert.ErrorContains(t, err, "repos.teams: bar/payments: not found")
+}
+
+var errNotFound = errors.New("not found")
+
+var _ accessChecker = fakeAccessChecker{}
+
+type fakeAccessChecker struct {
+	users []string
+	repos []string
+}
+
+func (f fakeAccessChecker) GetUserRepoNames(_ context.Context, user string) ([]string, error) {
+	for _, u := range f.users {
+		if u == user {
+			return nil, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// GetTopicRepoNames treats the users as orgs: any topic of a user is found.
+func (f fakeAccessChecker) GetTopicRepoNames(ctx context.Context, org string, _ string) ([]string, error) {
+	return f.GetUserRepoNames(ctx, org)
+}
+
+// GetTeamRepos treats the users as orgs: any team of a user is found.
+func (f fakeAccessChecker) GetTeamRepos(ctx context.Context, org string, _ string) ([]github.TeamRepo, error) {
+	_, err := f.GetUserRepoNames(ctx, org)
+	return nil, err
+}
+
+func (f fakeAccessChecker) GetRepoStats(_ context.Context, owner string, repo string) (github.RepoStats, error) {
+	for _, r := range f.repos {
+		if r == owner+"/"+repo {
+			return github.RepoStats{}, nil
+		}
+	}
+	return github.RepoStats{}, errNotFound
+}